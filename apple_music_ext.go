@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"time"
 
 	"github.com/guitaripod/musickitkat/models"
@@ -41,9 +42,32 @@ func (c *AppleMusicClient) doRequest(ctx context.Context, method, path string, p
 
 	req.Header.Set("Authorization", "Bearer "+c.DeveloperToken)
 	req.Header.Set("Accept", "application/json")
-	
 
-	return c.HTTPClient.Do(req)
+	if method != http.MethodGet {
+		return c.HTTPClient.Do(req)
+	}
+
+	cache := responseCache()
+	cacheKey := "applemusic|" + url
+	if body, ok := cache.Get(cacheKey); ok {
+		return cachedResponse(body), nil
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil || cache == nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if err := cache.Put(cacheKey, body, cache.appleMusicTTL); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to cache response: %v\n", err)
+	}
+
+	return cachedResponse(body), nil
 }
 
 func (c *AppleMusicClient) GetAlbumTracks(ctx context.Context, storefront, albumID string) ([]models.Song, error) {
@@ -68,12 +92,12 @@ func (c *AppleMusicClient) GetAlbumTracks(ctx context.Context, storefront, album
 			return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
 		}
 
-		var response models.SongsResponse
+		var response songsResponse
 		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
 			return nil, fmt.Errorf("failed to decode response: %w", err)
 		}
 
-		allTracks = append(allTracks, response.Data...)
+		allTracks = append(allTracks, response.songs()...)
 
 		if response.Next == "" || len(response.Data) < limit {
 			break
@@ -85,6 +109,45 @@ func (c *AppleMusicClient) GetAlbumTracks(ctx context.Context, storefront, album
 	return allTracks, nil
 }
 
+func (c *AppleMusicClient) GetArtistAlbums(ctx context.Context, storefront, artistID string) ([]models.Album, error) {
+	path := fmt.Sprintf("/catalog/%s/artists/%s/albums", storefront, artistID)
+
+	var allAlbums []models.Album
+	limit := 100
+	offset := 0
+
+	for {
+		params := url.Values{}
+		params.Set("limit", fmt.Sprintf("%d", limit))
+		params.Set("offset", fmt.Sprintf("%d", offset))
+
+		resp, err := c.doRequest(ctx, "GET", path, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to make request: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		}
+
+		var response models.AlbumsResponse
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		allAlbums = append(allAlbums, response.Data...)
+
+		if response.Next == "" || len(response.Data) < limit {
+			break
+		}
+
+		offset += limit
+	}
+
+	return allAlbums, nil
+}
+
 func (c *AppleMusicClient) GetPlaylistDetails(ctx context.Context, storefront, playlistID string) (*models.Playlist, error) {
 	path := fmt.Sprintf("/catalog/%s/playlists/%s", storefront, playlistID)
 	params := url.Values{}
@@ -112,6 +175,59 @@ func (c *AppleMusicClient) GetPlaylistDetails(ctx context.Context, storefront, p
 	return &playlistResp.Data[0], nil
 }
 
+func (c *AppleMusicClient) GetMusicVideo(ctx context.Context, storefront, videoID string) (*models.MusicVideo, error) {
+	path := fmt.Sprintf("/catalog/%s/music-videos/%s", storefront, videoID)
+
+	resp, err := c.doRequest(ctx, "GET", path, url.Values{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var videoResp models.MusicVideosResponse
+	if err := json.NewDecoder(resp.Body).Decode(&videoResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(videoResp.Data) == 0 {
+		return nil, fmt.Errorf("music video not found")
+	}
+
+	return &videoResp.Data[0], nil
+}
+
+func (c *AppleMusicClient) GetSong(ctx context.Context, storefront, songID string) (*models.Song, error) {
+	path := fmt.Sprintf("/catalog/%s/songs/%s", storefront, songID)
+
+	resp, err := c.doRequest(ctx, "GET", path, url.Values{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var songResp songsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&songResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	songs := songResp.songs()
+	if len(songs) == 0 {
+		return nil, fmt.Errorf("song not found")
+	}
+
+	return &songs[0], nil
+}
+
 func (c *AppleMusicClient) GetPlaylistTracks(ctx context.Context, storefront, playlistID string) ([]models.Song, error) {
 	path := fmt.Sprintf("/catalog/%s/playlists/%s", storefront, playlistID)
 	params := url.Values{}
@@ -168,12 +284,12 @@ func (c *AppleMusicClient) GetPlaylistTracks(ctx context.Context, storefront, pl
 		}
 		defer resp.Body.Close()
 
-		var songsResp models.SongsResponse
+		var songsResp songsResponse
 		if err := json.NewDecoder(resp.Body).Decode(&songsResp); err != nil {
 			return nil, fmt.Errorf("failed to decode songs: %w", err)
 		}
 
-		allTracks = append(allTracks, songsResp.Data...)
+		allTracks = append(allTracks, songsResp.songs()...)
 	}
 
 	return allTracks, nil
@@ -188,4 +304,42 @@ func joinIDs(ids []string) string {
 		result += id
 	}
 	return result
-}
\ No newline at end of file
+}
+
+// songsResponse decodes songs like models.SongsResponse, but keeps the
+// composer: musickitkat looks for "composer" while Apple Music sends
+// "composerName".
+type songsResponse struct {
+	Data []catalogSong `json:"data"`
+	Next string        `json:"next,omitempty"`
+}
+
+func (r songsResponse) songs() []models.Song {
+	songs := make([]models.Song, len(r.Data))
+	for i, song := range r.Data {
+		songs[i] = song.Song
+	}
+	return songs
+}
+
+type catalogSong struct {
+	models.Song
+}
+
+func (s *catalogSong) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &s.Song); err != nil {
+		return err
+	}
+	var extra struct {
+		Attributes struct {
+			ComposerName string `json:"composerName"`
+		} `json:"attributes"`
+	}
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return err
+	}
+	if s.Attributes.Composer == "" {
+		s.Attributes.Composer = extra.Attributes.ComposerName
+	}
+	return nil
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guitaripod/musickitkat/models"
+)
+
+type ReleaseKind string
+
+const (
+	ReleaseAlbum       ReleaseKind = "albums"
+	ReleaseSingle      ReleaseKind = "singles"
+	ReleaseEP          ReleaseKind = "eps"
+	ReleaseCompilation ReleaseKind = "compilations"
+)
+
+const defaultReleaseKinds = "albums,eps,singles"
+
+// releaseKind classifies an album the way Apple Music lists it on an artist
+// page. EPs have no attribute of their own, only the " - EP" name suffix.
+func releaseKind(album models.Album) ReleaseKind {
+	switch {
+	case album.Attributes.IsCompilation:
+		return ReleaseCompilation
+	case strings.HasSuffix(album.Attributes.Name, " - EP"):
+		return ReleaseEP
+	case album.Attributes.IsSingle || strings.HasSuffix(album.Attributes.Name, " - Single"):
+		return ReleaseSingle
+	default:
+		return ReleaseAlbum
+	}
+}
+
+func releaseYear(releaseDate string) int {
+	if len(releaseDate) < 4 {
+		return 0
+	}
+	year, err := strconv.Atoi(releaseDate[:4])
+	if err != nil {
+		return 0
+	}
+	return year
+}
+
+type DiscographyFilter struct {
+	Kinds    map[ReleaseKind]bool
+	FromYear int
+	ToYear   int
+}
+
+func ParseReleaseKinds(list string) (map[ReleaseKind]bool, error) {
+	kinds := make(map[ReleaseKind]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !strings.HasSuffix(name, "s") {
+			name += "s"
+		}
+		switch kind := ReleaseKind(name); kind {
+		case ReleaseAlbum, ReleaseSingle, ReleaseEP, ReleaseCompilation:
+			kinds[kind] = true
+		default:
+			return nil, fmt.Errorf("unknown release type %q (use albums, singles, eps or compilations)", name)
+		}
+	}
+	if len(kinds) == 0 {
+		return nil, fmt.Errorf("no release types selected")
+	}
+	return kinds, nil
+}
+
+func (f DiscographyFilter) Match(album models.Album) bool {
+	if len(f.Kinds) > 0 && !f.Kinds[releaseKind(album)] {
+		return false
+	}
+	year := releaseYear(album.Attributes.ReleaseDate)
+	if f.FromYear > 0 && year < f.FromYear {
+		return false
+	}
+	if f.ToYear > 0 && (year == 0 || year > f.ToYear) {
+		return false
+	}
+	return true
+}
+
+type ArtistDiscography struct {
+	ID     string
+	Name   string
+	Albums []models.Album
+}
+
+// GetArtistDiscography returns the artist's releases oldest first. Apple Music
+// lists clean and explicit editions separately; only the explicit one is kept.
+func (ems *ExtendedMusicSearcher) GetArtistDiscography(ctx context.Context, artistID string, storefront string) (*ArtistDiscography, error) {
+	ems.client.Catalog.SetStorefront(storefront)
+
+	artist, err := ems.client.Catalog.GetArtist(ctx, artistID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artist: %w", err)
+	}
+
+	apiClient := NewAppleMusicClient(ems.client.DeveloperToken)
+	albums, err := apiClient.GetArtistAlbums(ctx, storefront, artistID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artist albums: %w", err)
+	}
+
+	return &ArtistDiscography{
+		ID:     artist.ID,
+		Name:   artist.Attributes.Name,
+		Albums: dedupeAlbums(albums),
+	}, nil
+}
+
+func dedupeAlbums(albums []models.Album) []models.Album {
+	var unique []models.Album
+	seen := make(map[string]int)
+	for _, album := range albums {
+		key := strings.ToLower(album.Attributes.Name) + "|" + album.Attributes.ReleaseDate
+		if i, ok := seen[key]; ok {
+			if unique[i].Attributes.ContentRating == "clean" && album.Attributes.ContentRating != "clean" {
+				unique[i] = album
+			}
+			continue
+		}
+		seen[key] = len(unique)
+		unique = append(unique, album)
+	}
+
+	sort.SliceStable(unique, func(i, j int) bool {
+		return unique[i].Attributes.ReleaseDate < unique[j].Attributes.ReleaseDate
+	})
+	return unique
+}
+
+// albumDirName names an album's directory "<year> - <album>" so that a
+// discography sorts chronologically.
+func albumDirName(album models.Album) string {
+	name := album.Attributes.Name
+	if year := releaseYear(album.Attributes.ReleaseDate); year > 0 {
+		name = fmt.Sprintf("%d - %s", year, name)
+	}
+	return sanitizeFileName(name)
+}
+
+type ArtistRelease struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Kind        ReleaseKind `json:"kind"`
+	ReleaseDate string      `json:"release_date"`
+	TrackCount  int         `json:"track_count"`
+	URL         string      `json:"url"`
+}
+
+func NewArtistRelease(album models.Album) ArtistRelease {
+	return ArtistRelease{
+		ID:          album.ID,
+		Name:        album.Attributes.Name,
+		Kind:        releaseKind(album),
+		ReleaseDate: album.Attributes.ReleaseDate,
+		TrackCount:  album.Attributes.TrackCount,
+		URL:         album.Attributes.URL,
+	}
+}
+
+func executeArtist(args []string) error {
+	artistCmd := flag.NewFlagSet("artist", flag.ExitOnError)
+	formatFlag := artistCmd.String("format", "mp3", "Download format: mp3 or mp4 (default: mp3)")
+	outFlag := artistCmd.String("out", "downloads", "Output directory; releases go in <out>/<artist>/<year> - <album>")
+	concurrentFlag := artistCmd.Int("concurrent", defaultConcurrency, "Number of parallel downloads (default: 3)")
+	includeFlag := artistCmd.String("include", defaultReleaseKinds, "Release types to download: albums, singles, eps, compilations")
+	fromYearFlag := artistCmd.Int("from-year", 0, "Only releases from this year on")
+	toYearFlag := artistCmd.Int("to-year", 0, "Only releases up to this year")
+	trackTimeoutFlag := artistCmd.Duration("track-timeout", defaultTrackTimeout, "Give up on a download attempt after this long (0 = no limit)")
+	listFlag := artistCmd.Bool("list", false, "List matching releases without downloading")
+	debugFlag := artistCmd.Bool("debug", false, "Enable debug logging")
+	helpFlag := artistCmd.Bool("help", false, "Show help for artist command")
+	hFlag := artistCmd.Bool("h", false, "Show help for artist command")
+	artistCmd.BoolVar(jsonFlag, "json", *jsonFlag, "Print releases and download results as JSON")
+	registerCacheFlag(artistCmd)
+	registerDownloadFlags(artistCmd)
+
+	if err := artistCmd.Parse(reorderArgs(args, map[string]bool{"format": true, "out": true, "concurrent": true, "include": true, "from-year": true, "to-year": true, "track-timeout": true, "overwrite": true, "source": true, "name-template": true})); err != nil {
+		return err
+	}
+
+	if *helpFlag || *hFlag {
+		printArtistHelp()
+		os.Exit(0)
+	}
+
+	if artistCmd.NArg() == 0 {
+		return fmt.Errorf("Apple Music artist URL required")
+	}
+	artistURL := artistCmd.Arg(0)
+
+	kinds, err := ParseReleaseKinds(*includeFlag)
+	if err != nil {
+		return err
+	}
+	template, downloader, err := checkDownloadFlags()
+	if err != nil {
+		return err
+	}
+
+	resource, err := NewPlaylistURLParser().Parse(artistURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if resource.Type != ParsedArtist {
+		return fmt.Errorf("not an artist URL: %s", artistURL)
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	return downloadArtist(ctx, artistURL, resource, artistOptions{
+		batchOptions: batchOptions{
+			Format:       *formatFlag,
+			OutputDir:    *outFlag,
+			NameTemplate: template,
+			Downloader:   downloader,
+			Concurrency:  *concurrentFlag,
+			Debug:        *debugFlag,
+			TrackTimeout: *trackTimeoutFlag,
+		},
+		Filter: DiscographyFilter{Kinds: kinds, FromYear: *fromYearFlag, ToYear: *toYearFlag},
+		List:   *listFlag,
+	})
+}
+
+// artistOptions configures downloadArtist. OutputDir is the directory the
+// artist's directory is created in.
+type artistOptions struct {
+	batchOptions
+	Filter DiscographyFilter
+	List   bool
+}
+
+// downloadArtist lists the releases of the artist resource that match
+// opts.Filter and, unless opts.List is set, downloads each into its own
+// album directory.
+func downloadArtist(interruptCtx context.Context, artistURL string, resource *ParsedResource, opts artistOptions) error {
+	config, err := loadConfigWithOnboarding()
+	if err != nil {
+		return err
+	}
+	searcher, err := NewExtendedMusicSearcher(config)
+	if err != nil {
+		return fmt.Errorf("error creating music searcher: %w", err)
+	}
+
+	fmt.Fprintf(ui(), "Fetching discography...\n")
+	ctx, cancel := context.WithTimeout(interruptCtx, catalogFetchTimeout)
+	discography, err := searcher.GetArtistDiscography(ctx, resource.ID, resource.Storefront)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("error fetching artist: %w", err)
+	}
+
+	output := ArtistOutput{ID: discography.ID, Name: discography.Name, SourceURL: artistURL}
+	var albums []models.Album
+	for _, album := range discography.Albums {
+		if opts.Filter.Match(album) {
+			albums = append(albums, album)
+			output.Releases = append(output.Releases, NewArtistRelease(album))
+		}
+	}
+
+	fmt.Fprintf(ui(), "%s: %d of %d releases match\n", discography.Name, len(albums), len(discography.Albums))
+	for _, release := range output.Releases {
+		fmt.Fprintf(ui(), "  %s  %s (%s, %d tracks)\n", release.ReleaseDate, release.Name, strings.TrimSuffix(string(release.Kind), "s"), release.TrackCount)
+	}
+
+	if opts.List || len(albums) == 0 {
+		if *jsonFlag {
+			return printJSON(output)
+		}
+		return nil
+	}
+
+	artistDir := filepath.Join(opts.OutputDir, sanitizeFileName(discography.Name))
+	start := time.Now()
+	for i, album := range albums {
+		if interruptCtx.Err() != nil {
+			break
+		}
+		fmt.Fprintf(ui(), "\n[%d/%d] %s\n", i+1, len(albums), album.Attributes.Name)
+
+		ctx, cancel := context.WithTimeout(interruptCtx, catalogFetchTimeout)
+		albumTracks, err := searcher.GetAlbumWithTracks(ctx, album.ID, resource.Storefront)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(ui(), "Warning: skipping %s: %v\n", album.Attributes.Name, err)
+			continue
+		}
+
+		albumDir := filepath.Join(artistDir, albumDirName(album))
+		if err := os.MkdirAll(albumDir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+
+		metadata := CreateAlbumMetadata(albumTracks, album.Attributes.URL)
+		if saved, err := LoadPlaylistMetadata(MetadataFilePath(metadata, albumDir)); err == nil && saved.ID == metadata.ID {
+			resumed := metadata.ResumeFrom(saved)
+			fmt.Fprintf(ui(), "Resuming: %d of %d tracks already downloaded\n", resumed, len(albumTracks.Tracks))
+		}
+		if err := SavePlaylistMetadata(metadata, albumDir); err != nil {
+			fmt.Fprintf(ui(), "Warning: Failed to save metadata: %v\n", err)
+		}
+
+		// Templates with directories of their own name tracks from -out;
+		// flat ones name them inside the album's directory.
+		trackDir := albumDir
+		if strings.Contains(opts.NameTemplate, "/") {
+			trackDir = opts.OutputDir
+		}
+		albumOpts := opts.batchOptions
+		albumOpts.OutputDir = trackDir
+		albumOpts.SaveMetadata = true
+		albumOpts.MetadataPath = MetadataFilePath(metadata, albumDir)
+		albumOpts.Skip = metadata.DownloadedTrackIDs()
+		results, progress := runBatchDownload(interruptCtx, albumTracks.Tracks, metadata, albumOpts)
+
+		albumOutput := NewPlaylistOutput(metadata, album.Attributes.URL, results, progress)
+		output.Albums = append(output.Albums, albumOutput)
+		output.Total += albumOutput.Total
+		output.Completed += albumOutput.Completed
+		output.Failed += albumOutput.Failed
+		output.Cancelled += albumOutput.Cancelled
+	}
+	output.DurationSeconds = time.Since(start).Seconds()
+
+	printArtistSummary(output)
+
+	if *jsonFlag {
+		if err := printJSON(output); err != nil {
+			return err
+		}
+	}
+	if wasInterrupted(interruptCtx) {
+		return errInterrupted
+	}
+	return nil
+}
+
+func printArtistSummary(output ArtistOutput) {
+	fmt.Fprintf(ui(), "\n========== Discography Summary ==========\n")
+	fmt.Fprintf(ui(), "Artist: %s\n", output.Name)
+	fmt.Fprintf(ui(), "Releases: %d\n", len(output.Albums))
+	fmt.Fprintf(ui(), "Total tracks: %d\n", output.Total)
+	fmt.Fprintf(ui(), "Completed: %d\n", output.Completed)
+	fmt.Fprintf(ui(), "Failed: %d\n", output.Failed)
+	if output.Cancelled > 0 {
+		fmt.Fprintf(ui(), "Cancelled: %d\n", output.Cancelled)
+	}
+	fmt.Fprintf(ui(), "Duration: %s\n", time.Duration(output.DurationSeconds*float64(time.Second)).Round(time.Second))
+	fmt.Fprintf(ui(), "=========================================\n")
+
+	if output.Failed > 0 {
+		fmt.Fprintf(ui(), "\nFailed downloads:\n")
+		for _, album := range output.Albums {
+			for _, result := range album.Results {
+				if result.Error != nil && result.Outcome != OutcomeCancelled {
+					fmt.Fprintf(ui(), "- %s / %s: %v\n", album.Name, result.Job.Track.Name, result.Error)
+				}
+			}
+		}
+	}
+}
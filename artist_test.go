@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/guitaripod/musickitkat/models"
+)
+
+func testAlbum(id, name, releaseDate string, single, compilation bool) models.Album {
+	album := models.Album{}
+	album.ID = id
+	album.Attributes.Name = name
+	album.Attributes.ReleaseDate = releaseDate
+	album.Attributes.IsSingle = single
+	album.Attributes.IsCompilation = compilation
+	return album
+}
+
+func TestReleaseKind(t *testing.T) {
+	tests := []struct {
+		album models.Album
+		want  ReleaseKind
+	}{
+		{testAlbum("1", "Abbey Road", "1969-09-26", false, false), ReleaseAlbum},
+		{testAlbum("2", "Something - Single", "1969-10-06", true, false), ReleaseSingle},
+		{testAlbum("3", "Magical Mystery Tour - EP", "1967-12-08", false, false), ReleaseEP},
+		{testAlbum("4", "1", "2000-11-13", false, true), ReleaseCompilation},
+	}
+	for _, tt := range tests {
+		if got := releaseKind(tt.album); got != tt.want {
+			t.Errorf("releaseKind(%q) = %s; want %s", tt.album.Attributes.Name, got, tt.want)
+		}
+	}
+}
+
+func TestDiscographyFilterMatch(t *testing.T) {
+	kinds, err := ParseReleaseKinds("album, ep")
+	if err != nil {
+		t.Fatalf("ParseReleaseKinds returned an unexpected error: %v", err)
+	}
+	filter := DiscographyFilter{Kinds: kinds, FromYear: 1965, ToYear: 1969}
+
+	tests := []struct {
+		album models.Album
+		want  bool
+	}{
+		{testAlbum("1", "Abbey Road", "1969-09-26", false, false), true},
+		{testAlbum("2", "Please Please Me", "1963-03-22", false, false), false},
+		{testAlbum("3", "Let It Be", "1970-05-08", false, false), false},
+		{testAlbum("4", "Something - Single", "1969-10-06", true, false), false},
+		{testAlbum("5", "Magical Mystery Tour - EP", "1967-12-08", false, false), true},
+		{testAlbum("6", "Undated", "", false, false), false},
+	}
+	for _, tt := range tests {
+		if got := filter.Match(tt.album); got != tt.want {
+			t.Errorf("Match(%q) = %t; want %t", tt.album.Attributes.Name, got, tt.want)
+		}
+	}
+
+	if _, err := ParseReleaseKinds("albums,bootlegs"); err == nil {
+		t.Error("ParseReleaseKinds accepted an unknown release type")
+	}
+}
+
+func TestDedupeAlbums(t *testing.T) {
+	clean := testAlbum("1", "Album", "2001-01-01", false, false)
+	clean.Attributes.ContentRating = "clean"
+	explicit := testAlbum("2", "Album", "2001-01-01", false, false)
+	explicit.Attributes.ContentRating = "explicit"
+	older := testAlbum("3", "Debut", "1999-01-01", false, false)
+
+	var ids []string
+	for _, album := range dedupeAlbums([]models.Album{clean, explicit, older}) {
+		ids = append(ids, album.ID)
+	}
+	if len(ids) != 2 || ids[0] != "3" || ids[1] != "2" {
+		t.Errorf("dedupeAlbums() returned IDs %v; want [3 2]", ids)
+	}
+}
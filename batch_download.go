@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -17,21 +19,45 @@ type BatchDownloader struct {
 }
 
 type DownloadJob struct {
-	Track      SearchResult
-	Format     string
-	OutputDir  string
-	Debug      bool
-	RetryCount int
-	Index      int
+	Track        SearchResult  `json:"track"`
+	Format       string        `json:"format"`
+	OutputDir    string        `json:"output_dir"`
+	NameTemplate string        `json:"-"`
+	Downloader   *Downloader   `json:"-"`
+	Debug        bool          `json:"-"`
+	Timeout      time.Duration `json:"-"`
+	RetryCount   int           `json:"retry_count,omitempty"`
+	Index        int           `json:"index"`
 }
 
 type DownloadResult struct {
 	Job      DownloadJob
 	FilePath string
+	Outcome  DownloadOutcome
 	Error    error
 	Duration time.Duration
 }
 
+func (r DownloadResult) MarshalJSON() ([]byte, error) {
+	errString := ""
+	if r.Error != nil {
+		errString = r.Error.Error()
+	}
+	return json.Marshal(struct {
+		DownloadJob
+		FilePath        string          `json:"file_path,omitempty"`
+		Outcome         DownloadOutcome `json:"outcome,omitempty"`
+		Error           string          `json:"error,omitempty"`
+		DurationSeconds float64         `json:"duration_seconds"`
+	}{
+		DownloadJob:     r.Job,
+		FilePath:        r.FilePath,
+		Outcome:         r.Outcome,
+		Error:           errString,
+		DurationSeconds: r.Duration.Seconds(),
+	})
+}
+
 func NewBatchDownloader(concurrency int) *BatchDownloader {
 	if concurrency <= 0 {
 		concurrency = 3
@@ -39,8 +65,8 @@ func NewBatchDownloader(concurrency int) *BatchDownloader {
 	
 	return &BatchDownloader{
 		concurrency:   concurrency,
-		downloadQueue: make(chan DownloadJob, 100),
-		results:       make(chan DownloadResult, 100),
+		downloadQueue: make(chan DownloadJob, concurrency),
+		results:       make(chan DownloadResult, concurrency),
 		progress:      NewProgressTracker(),
 	}
 }
@@ -55,81 +81,117 @@ func (bd *BatchDownloader) Start(ctx context.Context) {
 func (bd *BatchDownloader) worker(ctx context.Context, workerID int) {
 	defer bd.wg.Done()
 	
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case job, ok := <-bd.downloadQueue:
-			if !ok {
-				return
-			}
-			
-			bd.progress.StartDownload(job.Track.ID, job.Track.Name, job.Track.ArtistName)
-			
-			startTime := time.Now()
-			filePath, err := bd.downloadTrack(job)
-			duration := time.Since(startTime)
-			
-			result := DownloadResult{
-				Job:      job,
-				FilePath: filePath,
-				Error:    err,
-				Duration: duration,
-			}
-			
-			if err != nil {
-				bd.progress.MarkFailed(job.Track.ID, err)
-			} else {
-				bd.progress.MarkCompleted(job.Track.ID, filePath)
-			}
-			
-			select {
-			case bd.results <- result:
-			case <-ctx.Done():
-				return
-			}
+	// Jobs still queued after ctx is done are drained as cancelled rather
+	// than dropped, so every queued track gets a result.
+	for job := range bd.downloadQueue {
+		if ctx.Err() != nil {
+			err := errNotStarted(ctx)
+			bd.progress.MarkCancelled(job.Track.ID, err)
+			bd.results <- DownloadResult{Job: job, Outcome: OutcomeCancelled, Error: err}
+			continue
 		}
+		
+		bd.progress.StartDownload(job.Track.ID, job.Track.Name, job.Track.ArtistName)
+		
+		startTime := time.Now()
+		filePath, outcome, err := bd.downloadTrack(ctx, job)
+		duration := time.Since(startTime)
+		
+		if err != nil && ctx.Err() != nil {
+			outcome = OutcomeCancelled
+		}
+		result := DownloadResult{
+			Job:      job,
+			FilePath: filePath,
+			Outcome:  outcome,
+			Error:    err,
+			Duration: duration,
+		}
+		
+		switch {
+		case outcome == OutcomeCancelled:
+			bd.progress.MarkCancelled(job.Track.ID, err)
+		case err != nil:
+			bd.progress.MarkFailed(job.Track.ID, err)
+		case outcome == OutcomeDownloaded:
+			bd.progress.MarkCompleted(job.Track.ID, filePath)
+		default:
+			bd.progress.MarkSkipped(job.Track.ID, filePath)
+		}
+		
+		// Always deliver the result, even after cancellation, so that
+		// the caller records what happened to tracks that were in flight.
+		bd.results <- result
 	}
 }
 
-func (bd *BatchDownloader) downloadTrack(job DownloadJob) (string, error) {
+func (bd *BatchDownloader) downloadTrack(ctx context.Context, job DownloadJob) (string, DownloadOutcome, error) {
 	maxRetries := 3
 	retryDelay := time.Second
 	
 	var lastErr error
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
-			time.Sleep(retryDelay * time.Duration(attempt))
+			if err := sleepContext(ctx, retryDelay*time.Duration(attempt)); err != nil {
+				return "", "", fmt.Errorf("download cancelled: %w", context.Cause(ctx))
+			}
 		}
 		
-		filePath, err := DownloadTrack(
-			job.Track.Name,
-			job.Track.ArtistName,
-			job.Track.ArtworkURL,
-			job.Format,
-			job.OutputDir,
-			job.Debug,
-		)
+		filePath, outcome, err := bd.downloadAttempt(ctx, job)
 		
 		if err == nil {
-			return filePath, nil
+			return filePath, outcome, nil
+		}
+		if ctx.Err() != nil {
+			return "", "", err
 		}
 		
 		lastErr = err
 		bd.progress.UpdateRetry(job.Track.ID, attempt+1, maxRetries)
 	}
 	
-	return "", fmt.Errorf("download failed after %d attempts: %w", maxRetries+1, lastErr)
+	return "", "", fmt.Errorf("download failed after %d attempts: %w", maxRetries+1, lastErr)
 }
 
-func (bd *BatchDownloader) QueueDownload(job DownloadJob) error {
-	select {
-	case bd.downloadQueue <- job:
-		bd.progress.QueueTrack(job.Track.ID, job.Track.Name, job.Track.ArtistName)
-		return nil
-	default:
-		return fmt.Errorf("download queue is full")
+// downloadAttempt runs one DownloadTrack call, giving up after job.Timeout
+// when it is set.
+func (bd *BatchDownloader) downloadAttempt(ctx context.Context, job DownloadJob) (string, DownloadOutcome, error) {
+	onProgress := func(p DownloadProgress) {
+		bd.progress.UpdateDownload(job.Track.ID, p)
+	}
+	if job.Timeout <= 0 {
+		return DownloadTrack(ctx, job.Track, job.Format, job.OutputDir, job.NameTemplate, job.Downloader, job.Debug, onProgress)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, job.Timeout)
+	defer cancel()
+	
+	filePath, outcome, err := DownloadTrack(attemptCtx, job.Track, job.Format, job.OutputDir, job.NameTemplate, job.Downloader, job.Debug, onProgress)
+	if err != nil && ctx.Err() == nil && attemptCtx.Err() != nil {
+		return "", "", fmt.Errorf("timed out after %s", job.Timeout)
+	}
+	return filePath, outcome, err
+}
+
+// QueueDownload hands job to the workers, blocking while they are all busy
+// and the queue is full. If ctx is done first, the track is recorded as
+// cancelled and the returned error says so.
+func (bd *BatchDownloader) QueueDownload(ctx context.Context, job DownloadJob) error {
+	bd.progress.QueueTrack(job.Track.ID, job.Track.Name, job.Track.ArtistName)
+	
+	if ctx.Err() == nil {
+		select {
+		case bd.downloadQueue <- job:
+			return nil
+		case <-ctx.Done():
+		}
 	}
+	err := errNotStarted(ctx)
+	bd.progress.MarkCancelled(job.Track.ID, err)
+	return err
+}
+
+func errNotStarted(ctx context.Context) error {
+	return fmt.Errorf("cancelled before download started: %w", context.Cause(ctx))
 }
 
 func (bd *BatchDownloader) Close() {
@@ -151,6 +213,8 @@ type ProgressTracker struct {
 	total      int32
 	completed  int32
 	failed     int32
+	skipped    int32
+	cancelled  int32
 	inProgress map[string]*TrackProgress
 	startTime  time.Time
 }
@@ -165,6 +229,7 @@ type TrackProgress struct {
 	StartTime  time.Time
 	EndTime    time.Time
 	RetryCount int
+	Download   DownloadProgress
 }
 
 type DownloadStatus string
@@ -175,6 +240,8 @@ const (
 	StatusCompleted   DownloadStatus = "completed"
 	StatusFailed      DownloadStatus = "failed"
 	StatusRetrying    DownloadStatus = "retrying"
+	StatusSkipped     DownloadStatus = "skipped"
+	StatusCancelled   DownloadStatus = "cancelled"
 )
 
 func NewProgressTracker() *ProgressTracker {
@@ -214,6 +281,17 @@ func (pt *ProgressTracker) UpdateRetry(id string, attempt, maxAttempts int) {
 	if track, ok := pt.inProgress[id]; ok {
 		track.Status = StatusRetrying
 		track.RetryCount = attempt
+		track.Download = DownloadProgress{}
+	}
+}
+
+// UpdateDownload records how far the current download of a track has got.
+func (pt *ProgressTracker) UpdateDownload(id string, progress DownloadProgress) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	if track, ok := pt.inProgress[id]; ok {
+		track.Download = progress
 	}
 }
 
@@ -229,6 +307,22 @@ func (pt *ProgressTracker) MarkCompleted(id, filePath string) {
 	}
 }
 
+// MarkSkipped records a track that didn't need downloading because its file
+// already existed or was reused from an earlier download. It counts as
+// completed.
+func (pt *ProgressTracker) MarkSkipped(id, filePath string) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	atomic.AddInt32(&pt.completed, 1)
+	atomic.AddInt32(&pt.skipped, 1)
+	if track, ok := pt.inProgress[id]; ok {
+		track.Status = StatusSkipped
+		track.FilePath = filePath
+		track.EndTime = time.Now()
+	}
+}
+
 func (pt *ProgressTracker) MarkFailed(id string, err error) {
 	pt.mu.Lock()
 	defer pt.mu.Unlock()
@@ -241,12 +335,34 @@ func (pt *ProgressTracker) MarkFailed(id string, err error) {
 	}
 }
 
+// MarkCancelled records a track that was stopped by Ctrl-C, either while
+// downloading or before it started. It counts as neither completed nor failed.
+func (pt *ProgressTracker) MarkCancelled(id string, err error) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	atomic.AddInt32(&pt.cancelled, 1)
+	if track, ok := pt.inProgress[id]; ok {
+		track.Status = StatusCancelled
+		track.Error = err
+		track.EndTime = time.Now()
+	}
+}
+
 func (pt *ProgressTracker) GetStats() (total, completed, failed int32) {
 	return atomic.LoadInt32(&pt.total),
 		atomic.LoadInt32(&pt.completed),
 		atomic.LoadInt32(&pt.failed)
 }
 
+func (pt *ProgressTracker) Skipped() int32 {
+	return atomic.LoadInt32(&pt.skipped)
+}
+
+func (pt *ProgressTracker) Cancelled() int32 {
+	return atomic.LoadInt32(&pt.cancelled)
+}
+
 func (pt *ProgressTracker) GetTrackProgress(id string) (*TrackProgress, bool) {
 	pt.mu.RLock()
 	defer pt.mu.RUnlock()
@@ -266,22 +382,50 @@ func (pt *ProgressTracker) GetAllProgress() map[string]*TrackProgress {
 	return result
 }
 
+// ActiveTracks returns copies of the tracks currently downloading or waiting
+// to retry, longest running first.
+func (pt *ProgressTracker) ActiveTracks() []TrackProgress {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
+
+	var active []TrackProgress
+	for _, track := range pt.inProgress {
+		if track.Status == StatusDownloading || track.Status == StatusRetrying {
+			active = append(active, *track)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].StartTime.Before(active[j].StartTime)
+	})
+	return active
+}
+
+func (pt *ProgressTracker) Elapsed() time.Duration {
+	return time.Since(pt.startTime)
+}
+
 func (pt *ProgressTracker) PrintSummary() {
 	total, completed, failed := pt.GetStats()
 	duration := time.Since(pt.startTime)
 	
-	fmt.Printf("\n========== Download Summary ==========\n")
-	fmt.Printf("Total tracks: %d\n", total)
-	fmt.Printf("Completed: %d\n", completed)
-	fmt.Printf("Failed: %d\n", failed)
-	fmt.Printf("Duration: %s\n", duration.Round(time.Second))
-	fmt.Printf("=====================================\n")
+	fmt.Fprintf(ui(), "\n========== Download Summary ==========\n")
+	fmt.Fprintf(ui(), "Total tracks: %d\n", total)
+	fmt.Fprintf(ui(), "Completed: %d\n", completed)
+	if skipped := pt.Skipped(); skipped > 0 {
+		fmt.Fprintf(ui(), "  (%d already downloaded)\n", skipped)
+	}
+	fmt.Fprintf(ui(), "Failed: %d\n", failed)
+	if cancelled := pt.Cancelled(); cancelled > 0 {
+		fmt.Fprintf(ui(), "Cancelled: %d\n", cancelled)
+	}
+	fmt.Fprintf(ui(), "Duration: %s\n", duration.Round(time.Second))
+	fmt.Fprintf(ui(), "=====================================\n")
 	
 	if failed > 0 {
-		fmt.Printf("\nFailed downloads:\n")
+		fmt.Fprintf(ui(), "\nFailed downloads:\n")
 		for _, track := range pt.GetAllProgress() {
 			if track.Status == StatusFailed {
-				fmt.Printf("- %s - %s: %v\n", track.Artist, track.Name, track.Error)
+				fmt.Fprintf(ui(), "- %s - %s: %v\n", track.Artist, track.Name, track.Error)
 			}
 		}
 	}
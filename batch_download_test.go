@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBatchDownloaderQueueBackpressure(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Without workers the queue fills up after two jobs and the third
+	// QueueDownload blocks until ctx is done.
+	downloader := NewBatchDownloader(2)
+	for i, id := range []string{"1", "2"} {
+		if err := downloader.QueueDownload(ctx, DownloadJob{Track: SearchResult{ID: id}, Index: i + 1}); err != nil {
+			t.Fatalf("QueueDownload returned an unexpected error: %v", err)
+		}
+	}
+	err := downloader.QueueDownload(ctx, DownloadJob{Track: SearchResult{ID: "3"}, Index: 3})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("QueueDownload on a full queue = %v; want a deadline error", err)
+	}
+
+	// Jobs already queued are drained as cancelled once workers start.
+	downloader.Start(ctx)
+	downloader.Close()
+
+	var results []DownloadResult
+	for result := range downloader.GetResults() {
+		results = append(results, result)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results; want 2", len(results))
+	}
+	for _, result := range results {
+		if result.Outcome != OutcomeCancelled || result.Error == nil {
+			t.Errorf("track %s: outcome = %q, error = %v; want cancelled with an error", result.Job.Track.ID, result.Outcome, result.Error)
+		}
+	}
+
+	progress := downloader.GetProgress()
+	if total, completed, failed := progress.GetStats(); total != 3 || completed != 0 || failed != 0 {
+		t.Errorf("total = %d, completed = %d, failed = %d; want 3, 0, 0", total, completed, failed)
+	}
+	if got := progress.Cancelled(); got != 3 {
+		t.Errorf("Cancelled() = %d; want 3", got)
+	}
+}
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSonglinkCacheTTL   = 7 * 24 * time.Hour
+	defaultAppleMusicCacheTTL = 24 * time.Hour
+	defaultCacheMaxSizeMB     = 100
+)
+
+var noCacheFlag = flag.Bool("no-cache", false, "Bypass the on-disk response cache")
+
+// registerCacheFlag makes -no-cache available on a subcommand's flag set.
+func registerCacheFlag(fs *flag.FlagSet) {
+	fs.BoolVar(noCacheFlag, "no-cache", *noCacheFlag, "Bypass the on-disk response cache")
+}
+
+type CacheConfig struct {
+	SonglinkTTL   string `json:"songlink_ttl,omitempty"`
+	AppleMusicTTL string `json:"apple_music_ttl,omitempty"`
+	MaxSizeMB     int    `json:"max_size_mb,omitempty"`
+}
+
+// ResponseCache stores API response bodies on disk, one file per key.
+type ResponseCache struct {
+	dir           string
+	maxSize       int64
+	songlinkTTL   time.Duration
+	appleMusicTTL time.Duration
+	mu            sync.Mutex
+}
+
+type cacheEntry struct {
+	Key       string    `json:"key"`
+	StoredAt  time.Time `json:"stored_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Body      []byte    `json:"body"`
+}
+
+type CacheStats struct {
+	Dir       string `json:"dir"`
+	Entries   int    `json:"entries"`
+	Expired   int    `json:"expired"`
+	SizeBytes int64  `json:"size_bytes"`
+	MaxBytes  int64  `json:"max_bytes"`
+}
+
+func GetCacheDir() (string, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), "cache"), nil
+}
+
+func NewResponseCache(dir string, config CacheConfig) (*ResponseCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	cache := &ResponseCache{
+		dir:           dir,
+		maxSize:       defaultCacheMaxSizeMB << 20,
+		songlinkTTL:   defaultSonglinkCacheTTL,
+		appleMusicTTL: defaultAppleMusicCacheTTL,
+	}
+	if config.MaxSizeMB > 0 {
+		cache.maxSize = int64(config.MaxSizeMB) << 20
+	}
+	if config.SonglinkTTL != "" {
+		ttl, err := time.ParseDuration(config.SonglinkTTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache songlink_ttl: %w", err)
+		}
+		cache.songlinkTTL = ttl
+	}
+	if config.AppleMusicTTL != "" {
+		ttl, err := time.ParseDuration(config.AppleMusicTTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache apple_music_ttl: %w", err)
+		}
+		cache.appleMusicTTL = ttl
+	}
+
+	return cache, nil
+}
+
+var (
+	sharedCache     *ResponseCache
+	sharedCacheOnce sync.Once
+)
+
+// responseCache returns the cache shared by the API clients, or nil when
+// caching is disabled with -no-cache or the cache directory is unusable.
+func responseCache() *ResponseCache {
+	if *noCacheFlag {
+		return nil
+	}
+	sharedCacheOnce.Do(func() {
+		dir, err := GetCacheDir()
+		if err != nil {
+			return
+		}
+		var cacheConfig CacheConfig
+		if config, err := LoadConfig(); err == nil {
+			cacheConfig = config.Cache
+		}
+		cache, err := NewResponseCache(dir, cacheConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: response cache disabled: %v\n", err)
+			return
+		}
+		sharedCache = cache
+	})
+	return sharedCache
+}
+
+func (c *ResponseCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get returns the cached body for key if present and not expired. Calling
+// Get on a nil cache always misses.
+func (c *ResponseCache) Get(key string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		return nil, false
+	}
+	if time.Now().After(entry.ExpiresAt) {
+		os.Remove(c.path(key))
+		return nil, false
+	}
+
+	return entry.Body, true
+}
+
+// Put stores body under key for ttl and evicts the oldest entries if the
+// cache grows beyond its size limit.
+func (c *ResponseCache) Put(key string, body []byte, ttl time.Duration) error {
+	if c == nil || ttl <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	data, err := json.Marshal(cacheEntry{
+		Key:       key,
+		StoredAt:  now,
+		ExpiresAt: now.Add(ttl),
+		Body:      body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tmp, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	return c.evict()
+}
+
+func (c *ResponseCache) evict() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	type file struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []file
+	var total int64
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, file{filepath.Join(c.dir, e.Name()), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+
+	if total <= c.maxSize {
+		return nil
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	for _, f := range files {
+		if total <= c.maxSize {
+			break
+		}
+		if err := os.Remove(f.path); err == nil {
+			total -= f.size
+		}
+	}
+
+	return nil
+}
+
+func (c *ResponseCache) Clear() (int, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	removed := 0
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.dir, e.Name())); err != nil {
+			return removed, fmt.Errorf("failed to remove cache entry: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func (c *ResponseCache) Stats() (*CacheStats, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	stats := &CacheStats{Dir: c.dir, MaxBytes: c.maxSize}
+	now := time.Now()
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		stats.Entries++
+		stats.SizeBytes += info.Size()
+
+		data, err := os.ReadFile(filepath.Join(c.dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		var entry cacheEntry
+		if json.Unmarshal(data, &entry) == nil && now.After(entry.ExpiresAt) {
+			stats.Expired++
+		}
+	}
+	return stats, nil
+}
+
+// trackingParams are query parameters added by share sheets that don't
+// change what a URL points to.
+var trackingParams = map[string]bool{
+	"si":  true,
+	"ls":  true,
+	"app": true,
+	"at":  true,
+	"ct":  true,
+}
+
+// normalizeCacheURL lowercases the scheme and host, drops fragments and
+// tracking parameters and sorts the remaining query so equivalent URLs share
+// a cache entry.
+func normalizeCacheURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return strings.TrimSpace(rawURL)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	query := u.Query()
+	for key := range query {
+		if trackingParams[key] || strings.HasPrefix(key, "utm_") {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// cachedResponse wraps a cached body in an *http.Response so callers can
+// treat cache hits like network responses.
+func cachedResponse(body []byte) *http.Response {
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(bytes.NewReader(body)),
+	}
+}
+
+func executeCache(args []string) error {
+	cacheCmd := flag.NewFlagSet("cache", flag.ExitOnError)
+	cacheCmd.BoolVar(jsonFlag, "json", *jsonFlag, "Print cache statistics as JSON")
+	helpFlag := cacheCmd.Bool("help", false, "Show help for cache command")
+	hFlag := cacheCmd.Bool("h", false, "Show help for cache command")
+
+	if err := cacheCmd.Parse(reorderArgs(args, nil)); err != nil {
+		return err
+	}
+
+	if *helpFlag || *hFlag || cacheCmd.NArg() == 0 {
+		printCacheHelp()
+		os.Exit(0)
+	}
+
+	dir, err := GetCacheDir()
+	if err != nil {
+		return err
+	}
+	config, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+	cache, err := NewResponseCache(dir, config.Cache)
+	if err != nil {
+		return err
+	}
+
+	switch cacheCmd.Arg(0) {
+	case "clear":
+		removed, err := cache.Clear()
+		if err != nil {
+			return err
+		}
+		if *jsonFlag {
+			return printJSON(map[string]int{"removed": removed})
+		}
+		fmt.Printf("Removed %d cached responses from %s\n", removed, dir)
+	case "stats":
+		stats, err := cache.Stats()
+		if err != nil {
+			return err
+		}
+		if *jsonFlag {
+			return printJSON(stats)
+		}
+		fmt.Printf("Location: %s\n", stats.Dir)
+		fmt.Printf("Entries:  %d (%d expired)\n", stats.Entries, stats.Expired)
+		fmt.Printf("Size:     %.1f MB of %.1f MB\n", float64(stats.SizeBytes)/(1<<20), float64(stats.MaxBytes)/(1<<20))
+	default:
+		return fmt.Errorf("unknown cache subcommand %q (use clear or stats)", cacheCmd.Arg(0))
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestResponseCache(t *testing.T) {
+	cache, err := NewResponseCache(t.TempDir(), CacheConfig{})
+	if err != nil {
+		t.Fatalf("NewResponseCache returned an unexpected error: %v", err)
+	}
+
+	if err := cache.Put("fresh", []byte(`{"ok":true}`), time.Hour); err != nil {
+		t.Fatalf("Put returned an unexpected error: %v", err)
+	}
+	if body, ok := cache.Get("fresh"); !ok || string(body) != `{"ok":true}` {
+		t.Errorf("Get(fresh) = %q, %v; want cached body", body, ok)
+	}
+
+	if err := cache.Put("stale", []byte(`{}`), time.Nanosecond); err != nil {
+		t.Fatalf("Put returned an unexpected error: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, ok := cache.Get("stale"); ok {
+		t.Errorf("Get(stale) returned an expired entry")
+	}
+	if _, err := os.Stat(cache.path("stale")); !os.IsNotExist(err) {
+		t.Errorf("expired entry was not removed from disk")
+	}
+
+	var nilCache *ResponseCache
+	if _, ok := nilCache.Get("fresh"); ok {
+		t.Errorf("Get on a nil cache should miss")
+	}
+}
+
+func TestResponseCacheEviction(t *testing.T) {
+	cache, err := NewResponseCache(t.TempDir(), CacheConfig{})
+	if err != nil {
+		t.Fatalf("NewResponseCache returned an unexpected error: %v", err)
+	}
+	cache.maxSize = 600
+
+	body := make([]byte, 200)
+	for _, key := range []string{"a", "b", "c"} {
+		if err := cache.Put(key, body, time.Hour); err != nil {
+			t.Fatalf("Put(%q) returned an unexpected error: %v", key, err)
+		}
+		past := time.Now().Add(-time.Hour)
+		if key == "a" {
+			os.Chtimes(cache.path(key), past, past)
+		}
+	}
+
+	if _, ok := cache.Get("a"); ok {
+		t.Errorf("oldest entry should have been evicted")
+	}
+	if _, ok := cache.Get("c"); !ok {
+		t.Errorf("newest entry should still be cached")
+	}
+}
+
+func TestNormalizeCacheURL(t *testing.T) {
+	tests := map[string]string{
+		"https://open.spotify.com/track/2Xtsv7BUMrNodQWH2JPOc0?si=abc123":     "https://open.spotify.com/track/2Xtsv7BUMrNodQWH2JPOc0",
+		"HTTPS://Music.Apple.com/fi/album/caravan/1572919347?i=1572919354&ls": "https://music.apple.com/fi/album/caravan/1572919347?i=1572919354",
+		"https://music.youtube.com/watch?v=abc&utm_source=share#t=10":         "https://music.youtube.com/watch?v=abc",
+		"https://music.apple.com/us/album/abbey-road/401469823/":              "https://music.apple.com/us/album/abbey-road/401469823",
+	}
+	for input, expected := range tests {
+		if got := normalizeCacheURL(input); got != expected {
+			t.Errorf("normalizeCacheURL(%q) = %q; want %q", input, got, expected)
+		}
+	}
+}
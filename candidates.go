@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Candidate is one result of a yt-dlp search.
+type Candidate struct {
+	ID         string  `json:"id"`
+	Title      string  `json:"title"`
+	Uploader   string  `json:"uploader"`
+	Channel    string  `json:"channel"`
+	Duration   float64 `json:"duration"`
+	URL        string  `json:"url"`
+	WebpageURL string  `json:"webpage_url"`
+}
+
+// Link is the URL to download the candidate from.
+func (c Candidate) Link() string {
+	if c.WebpageURL != "" {
+		return c.WebpageURL
+	}
+	return c.URL
+}
+
+const (
+	candidateCount           = 8
+	defaultDurationTolerance = 15 * time.Second
+)
+
+// badKeywords mark uploads that usually aren't the studio recording. They
+// only count against a candidate when the Apple Music title doesn't contain
+// them too.
+var badKeywords = []string{
+	"live", "cover", "remix", "sped up", "slowed", "nightcore", "reverb",
+	"karaoke", "instrumental", "8d", "loop", "1 hour", "10 hours", "reaction",
+}
+
+// searchCandidates lists up to candidateCount results for query, e.g.
+// "ytsearch" and "Song Artist". With flat set, yt-dlp only reads the search
+// results page, which is much faster but not supported by every site.
+func searchCandidates(ctx context.Context, prefix, query string, flat, debug bool) ([]Candidate, error) {
+	args := []string{
+		fmt.Sprintf("%s%d:%s", prefix, candidateCount, query),
+		"--dump-json",
+		"--no-download",
+		"--no-warnings",
+		"--ignore-errors",
+	}
+	if flat {
+		args = append(args, "--flat-playlist")
+	}
+
+	cmd := commandContext(ctx, "yt-dlp", args...)
+	if debug {
+		cmd.Stderr = ui()
+	}
+	output, err := cmd.Output()
+	if err != nil && len(output) == 0 {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+
+	var candidates []Candidate
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var c Candidate
+		if err := json.Unmarshal(scanner.Bytes(), &c); err == nil && c.Link() != "" {
+			candidates = append(candidates, c)
+		}
+	}
+	return candidates, nil
+}
+
+type scoredCandidate struct {
+	Candidate
+	Score float64
+}
+
+// rankCandidates scores candidates against track, best first. Candidates
+// whose duration is off by more than tolerance are dropped, even if that
+// leaves none.
+func rankCandidates(track SearchResult, candidates []Candidate, tolerance time.Duration) []scoredCandidate {
+	var ranked []scoredCandidate
+	for _, c := range candidates {
+		if score, ok := scoreCandidate(track, c, tolerance); ok {
+			ranked = append(ranked, scoredCandidate{c, score})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+	return ranked
+}
+
+// scoreCandidate rates how likely c is the studio recording of track. It
+// reports false when the durations are further apart than tolerance.
+func scoreCandidate(track SearchResult, c Candidate, tolerance time.Duration) (float64, bool) {
+	score := 0.0
+
+	if track.DurationMillis > 0 && c.Duration > 0 {
+		want := float64(track.DurationMillis) / 1000
+		diff := math.Abs(c.Duration - want)
+		if diff > tolerance.Seconds() {
+			return 0, false
+		}
+		score += 40 * (1 - diff/tolerance.Seconds())
+	}
+
+	score += 30 * wordOverlap(track.Name, c.Title)
+	score += 20 * wordOverlap(track.ArtistName, c.Title+" "+c.Uploader+" "+c.Channel)
+
+	channel := c.Channel
+	if channel == "" {
+		channel = c.Uploader
+	}
+	switch {
+	case strings.HasSuffix(channel, " - Topic"):
+		score += 15
+	case strings.Contains(strings.ToLower(channel), "vevo"):
+		score += 10
+	}
+	if normalizeName(channel) == normalizeName(track.ArtistName) {
+		score += 10
+	}
+
+	title := " " + strings.Join(words(c.Title), " ") + " "
+	wanted := " " + strings.Join(words(track.Name), " ") + " "
+	for _, keyword := range badKeywords {
+		if strings.Contains(title, " "+keyword+" ") && !strings.Contains(wanted, " "+keyword+" ") {
+			score -= 25
+		}
+	}
+	return score, true
+}
+
+// wordOverlap returns the share of want's words that appear in got.
+func wordOverlap(want, got string) float64 {
+	wantWords := words(want)
+	if len(wantWords) == 0 {
+		return 0
+	}
+	have := make(map[string]bool)
+	for _, w := range words(got) {
+		have[w] = true
+	}
+	found := 0
+	for _, w := range wantWords {
+		if have[w] {
+			found++
+		}
+	}
+	return float64(found) / float64(len(wantWords))
+}
+
+// words splits s into lowercase words of letters and digits.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
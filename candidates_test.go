@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRankCandidates(t *testing.T) {
+	track := SearchResult{Name: "Here Comes the Sun", ArtistName: "The Beatles", DurationMillis: 185733}
+	candidates := []Candidate{
+		{ID: "loop", Title: "Here Comes the Sun 10 Hours", Channel: "Loops", Duration: 36000},
+		{ID: "live", Title: "The Beatles - Here Comes the Sun (Live)", Channel: "Fan Uploads", Duration: 190},
+		{ID: "cover", Title: "Here Comes The Sun - acoustic cover", Channel: "Some Singer", Duration: 183},
+		{ID: "lyrics", Title: "The Beatles - Here Comes The Sun (Lyrics)", Channel: "Lyric Vids", Duration: 189},
+		{ID: "topic", Title: "Here Comes The Sun (Remastered 2009)", Channel: "The Beatles - Topic", Duration: 186},
+	}
+
+	ranked := rankCandidates(track, candidates, 15*time.Second)
+	if len(ranked) != 4 {
+		t.Fatalf("got %d candidates; want 4 with the 10 hour loop rejected", len(ranked))
+	}
+	if ranked[0].ID != "topic" {
+		t.Errorf("best candidate = %q; want topic", ranked[0].ID)
+	}
+	for _, c := range ranked[:2] {
+		if c.ID == "live" || c.ID == "cover" {
+			t.Errorf("%s ranked above a studio upload: %+v", c.ID, ranked)
+		}
+	}
+}
+
+func TestScoreCandidateKeepsWantedKeywords(t *testing.T) {
+	track := SearchResult{Name: "Hurt (Live)", ArtistName: "Nine Inch Nails", DurationMillis: 300000}
+	live := Candidate{Title: "Nine Inch Nails - Hurt (Live)", Duration: 301}
+	studio := Candidate{Title: "Nine Inch Nails - Hurt", Duration: 300}
+
+	liveScore, _ := scoreCandidate(track, live, 15*time.Second)
+	studioScore, _ := scoreCandidate(track, studio, 15*time.Second)
+	if liveScore <= studioScore {
+		t.Errorf("live score %.1f <= studio score %.1f for a live track", liveScore, studioScore)
+	}
+}
+
+func TestScoreCandidateWithoutDuration(t *testing.T) {
+	// Without an Apple Music duration nothing is rejected.
+	track := SearchResult{Name: "Song", ArtistName: "Artist"}
+	if _, ok := scoreCandidate(track, Candidate{Title: "Song", Duration: 36000}, 15*time.Second); !ok {
+		t.Error("scoreCandidate rejected a candidate although the track has no duration")
+	}
+}
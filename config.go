@@ -8,11 +8,21 @@ import (
 )
 
 type Config struct {
-	TeamID       string `json:"team_id"`
-	KeyID        string `json:"key_id"`
-	PrivateKey   string `json:"private_key"`
-	MusicID      string `json:"music_id"`
-	ConfigExists bool   `json:"-"`
+	TeamID                    string            `json:"team_id"`
+	KeyID                     string            `json:"key_id"`
+	PrivateKey                string            `json:"private_key"`
+	MusicID                   string            `json:"music_id"`
+	Templates                 map[string]string `json:"templates,omitempty"`
+	Cache                     CacheConfig       `json:"cache,omitempty"`
+	SonglinkAPIKey            string            `json:"songlink_api_key,omitempty"`
+	SonglinkRequestsPerMinute int               `json:"songlink_requests_per_minute,omitempty"`
+	Country                   string            `json:"country,omitempty"`
+	SongIfSingle              bool              `json:"song_if_single,omitempty"`
+	Overwrite                 string            `json:"overwrite,omitempty"`
+	NameTemplate              string            `json:"name_template,omitempty"`
+	NameTemplates             map[string]string `json:"name_templates,omitempty"`
+	Sources                   SourcesConfig     `json:"sources,omitempty"`
+	ConfigExists              bool              `json:"-"`
 }
 
 func GetConfigPath() (string, error) {
@@ -72,4 +82,4 @@ func (c *Config) SaveConfig() error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConvertRecord is one row of convert output.
+type ConvertRecord struct {
+	URL     string            `json:"url"`
+	PageURL string            `json:"page_url,omitempty"`
+	Title   string            `json:"title,omitempty"`
+	Artist  string            `json:"artist,omitempty"`
+	Links   map[string]string `json:"links,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// resolveAllPlatforms looks up searchURL without applying the output flags,
+// so every platform link is kept.
+func resolveAllPlatforms(ctx context.Context, searchURL string) (*LinksOutput, error) {
+	linksResponse, err := FetchLinks(ctx, searchURL)
+	if err != nil {
+		return nil, err
+	}
+	return &LinksOutput{URL: searchURL, ShareData: NewShareData(linksResponse)}, nil
+}
+
+func NewConvertRecords(resolutions []LinkResolution) []ConvertRecord {
+	records := make([]ConvertRecord, len(resolutions))
+	for i, r := range resolutions {
+		records[i].URL = r.URL
+		if r.Err != nil {
+			records[i].Error = r.Err.Error()
+			continue
+		}
+		records[i].PageURL = r.Result.PageURL
+		records[i].Title = r.Result.Title
+		records[i].Artist = r.Result.Artist
+		records[i].Links = r.Result.Links
+	}
+	return records
+}
+
+// readURLFile reads URLs from a text file with one URL per line, or from the
+// first column containing URLs in a CSV file.
+func readURLFile(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open input file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return readURLsFromCSV(r)
+	}
+	return readURLs(r)
+}
+
+func readURLsFromCSV(r io.Reader) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+
+	column := -1
+	var urls []string
+	for _, row := range rows {
+		if column < 0 {
+			for i, field := range row {
+				if isHTTPURL(field) {
+					column = i
+					break
+				}
+			}
+			if column < 0 {
+				// Header row or a row without URLs.
+				continue
+			}
+		}
+		if column < len(row) && isHTTPURL(row[column]) {
+			urls = append(urls, strings.TrimSpace(row[column]))
+		}
+	}
+	return urls, nil
+}
+
+func isHTTPURL(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// WriteConvertCSV writes one row per record with a column for every platform
+// that appears in any of the records.
+func WriteConvertCSV(w io.Writer, records []ConvertRecord) error {
+	links := make(LinksByPlatform)
+	for _, record := range records {
+		for platform := range record.Links {
+			links[platform] = PlatformMusic{}
+		}
+	}
+	platforms := links.Platforms()
+
+	writer := csv.NewWriter(w)
+	header := append([]string{"url", "page_url", "title", "artist"}, platforms...)
+	header = append(header, "error")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		row := []string{record.URL, record.PageURL, record.Title, record.Artist}
+		for _, platform := range platforms {
+			row = append(row, record.Links[platform])
+		}
+		row = append(row, record.Error)
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func executeConvert(args []string) error {
+	convertCmd := flag.NewFlagSet("convert", flag.ExitOnError)
+	outFlag := convertCmd.String("out", "-", "Output file (default: stdout)")
+	formatFlag := convertCmd.String("format", "", "Output format: csv or json (default: from -out extension, else csv)")
+	concurrentFlag := convertCmd.Int("concurrent", 4, "Number of URLs to resolve in parallel")
+	registerLookupFlags(convertCmd)
+	registerCacheFlag(convertCmd)
+	helpFlag := convertCmd.Bool("help", false, "Show help for convert command")
+	hFlag := convertCmd.Bool("h", false, "Show help for convert command")
+
+	if err := convertCmd.Parse(reorderArgs(args, map[string]bool{"out": true, "format": true, "concurrent": true, "country": true})); err != nil {
+		return err
+	}
+
+	if *helpFlag || *hFlag {
+		printConvertHelp()
+		os.Exit(0)
+	}
+
+	if convertCmd.NArg() == 0 {
+		return fmt.Errorf("input file required")
+	}
+
+	format := strings.ToLower(*formatFlag)
+	if format == "" {
+		format = "csv"
+		if strings.EqualFold(filepath.Ext(*outFlag), ".json") || (*jsonFlag && *outFlag == "-") {
+			format = "json"
+		}
+	}
+	if format != "csv" && format != "json" {
+		return fmt.Errorf("unsupported output format: %s", *formatFlag)
+	}
+
+	var urls []string
+	for _, path := range convertCmd.Args() {
+		fileURLs, err := readURLFile(path)
+		if err != nil {
+			return err
+		}
+		urls = append(urls, fileURLs...)
+	}
+	if len(urls) == 0 {
+		return fmt.Errorf("no URLs found in input")
+	}
+
+	fmt.Fprintf(os.Stderr, "Converting %d URLs...\n", len(urls))
+	ctx, stop := interruptContext()
+	defer stop()
+	records := NewConvertRecords(resolveAll(ctx, urls, *concurrentFlag, resolveAllPlatforms))
+	if wasInterrupted(ctx) {
+		return errInterrupted
+	}
+
+	if err := writeConvertOutput(*outFlag, format, records); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, record := range records {
+		if record.Error != "" {
+			failed++
+		}
+	}
+	destination := *outFlag
+	if destination == "-" {
+		destination = "stdout"
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d results to %s (%d failed)\n", len(records), destination, failed)
+
+	return nil
+}
+
+func writeConvertOutput(path, format string, records []ConvertRecord) error {
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if format == "json" {
+		return writeJSON(w, records)
+	}
+	return WriteConvertCSV(w, records)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadURLsFromCSV(t *testing.T) {
+	input := "name,link\nCaravan,https://music.apple.com/fi/album/caravan/1572919347?i=1572919354\nBroken,\nAbbey Road, https://music.apple.com/us/album/abbey-road/401469823\n"
+	urls, err := readURLsFromCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("readURLsFromCSV returned an unexpected error: %v", err)
+	}
+	expected := []string{
+		"https://music.apple.com/fi/album/caravan/1572919347?i=1572919354",
+		"https://music.apple.com/us/album/abbey-road/401469823",
+	}
+	if strings.Join(urls, " ") != strings.Join(expected, " ") {
+		t.Errorf("readURLsFromCSV() = %q; want %q", urls, expected)
+	}
+}
+
+func TestWriteConvertCSV(t *testing.T) {
+	records := NewConvertRecords([]LinkResolution{
+		{
+			URL: "https://music.apple.com/us/song/1",
+			Result: &LinksOutput{ShareData: ShareData{
+				PageURL: "https://song.link/i/1",
+				Title:   "Caravan",
+				Links: map[string]string{
+					"tidal":   "https://listen.tidal.com/track/1",
+					"spotify": "https://open.spotify.com/track/1",
+				},
+			}},
+		},
+		{URL: "https://music.apple.com/us/song/2", Err: errors.New("not found")},
+	})
+
+	var sb strings.Builder
+	if err := WriteConvertCSV(&sb, records); err != nil {
+		t.Fatalf("WriteConvertCSV returned an unexpected error: %v", err)
+	}
+
+	expected := "url,page_url,title,artist,spotify,tidal,error\n" +
+		"https://music.apple.com/us/song/1,https://song.link/i/1,Caravan,,https://open.spotify.com/track/1,https://listen.tidal.com/track/1,\n" +
+		"https://music.apple.com/us/song/2,,,,,,not found\n"
+	if sb.String() != expected {
+		t.Errorf("WriteConvertCSV() =\n%s\nwant\n%s", sb.String(), expected)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+   "context"
    "fmt"
    "io"
    "net/http"
@@ -11,72 +12,146 @@ import (
    "strings"
 )
 
-func DownloadTrack(song, artist, artworkURL, format, outDir string, debug bool) (string, error) {
-   ytdlpPath, err := exec.LookPath("yt-dlp")
+// DownloadOutcome says how DownloadTrack produced its file.
+type DownloadOutcome string
+
+const (
+   OutcomeDownloaded DownloadOutcome = "downloaded"
+   // OutcomeSkipped means the file was already in the output directory.
+   OutcomeSkipped DownloadOutcome = "skipped"
+   // OutcomeReused means the track was copied from an earlier download
+   // recorded in the download index.
+   OutcomeReused DownloadOutcome = "reused"
+   // OutcomeCancelled marks a batch track that Ctrl-C stopped before it
+   // finished or started.
+   OutcomeCancelled DownloadOutcome = "cancelled"
+)
+
+// DownloadTrack saves track into outDir under the name rendered from the
+// name template (see checkDownloadFlags; "" means "<Artist> - <Song>"),
+// applying the overwrite policy to existing files and reusing earlier
+// downloads of the same Apple Music ID or ISRC from the download index.
+// Anything else is fetched with downloader. Downloaded files are tagged with
+// the track's catalog metadata.
+func DownloadTrack(ctx context.Context, track SearchResult, format, outDir, template string, downloader *Downloader, debug bool, onProgress ProgressFunc) (string, DownloadOutcome, error) {
+   policy, err := overwritePolicy()
+   if err != nil {
+       return "", "", err
+   }
+   format = strings.ToLower(format)
+   if format != "mp3" && format != "mp4" {
+       return "", "", fmt.Errorf("unsupported format: %s", format)
+   }
+   if template == "" {
+       template = defaultNameTemplate
+   }
+   name, err := renderName(template, track)
    if err != nil {
-       return "", fmt.Errorf("yt-dlp not found in PATH. Please install it: brew install yt-dlp (macOS) or see README for other systems")
+       return "", "", err
+   }
+   outPath := filepath.Join(outDir, name)
+   if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+       return "", "", fmt.Errorf("failed to create output directory: %w", err)
    }
 
-   if err := checkYtDlpVersion(ytdlpPath, debug); err != nil {
-       return "", err
+   index := downloadIndex()
+   var indexed IndexEntry
+   found := false
+   if policy != OverwriteReplace {
+       indexed, found = index.Lookup(track, format)
    }
-   baseName := sanitizeFileName(fmt.Sprintf("%s - %s", artist, song))
-   if err := os.MkdirAll(outDir, 0755); err != nil {
-       return "", fmt.Errorf("failed to create output directory: %w", err)
+   // A file the index already knows as this track is the earlier download,
+   // not a name clash to rename around.
+   if found && indexed.isAt(outPath+"."+format) {
+       return outPath + "." + format, OutcomeSkipped, nil
    }
-   switch strings.ToLower(format) {
-   case "mp3":
-       outputTemplate := filepath.Join(outDir, baseName+".%(ext)s")
-       searchQueries := []string{
-           fmt.Sprintf("ytsearch1:%s %s lyrics", song, artist),
-           fmt.Sprintf("ytsearch1:%s %s topic", song, artist),
-           fmt.Sprintf("ytsearch1:%s %s", song, artist),
+   if _, err := os.Stat(outPath + "." + format); err == nil {
+       switch policy {
+       case OverwriteSkip:
+           return outPath + "." + format, OutcomeSkipped, nil
+       case OverwriteRename:
+           outPath = uniqueOutputPath(outPath, format)
        }
+   }
 
-       var lastErr error
-       for _, query := range searchQueries {
-           args := []string{
-               query,
-               "--extract-audio",
-               "--audio-format", "mp3",
-               "--audio-quality", "192K",
-               "--embed-thumbnail",
-               "--add-metadata",
-               "--output", outputTemplate,
-               "--no-check-certificates",
-               "--no-playlist",
-               "--no-warnings",
-               "--ignore-errors",
-           }
-           cmd := exec.Command("yt-dlp", args...)
-           if debug {
-               fmt.Printf("Trying search: %s\n", query)
-               cmd.Stdout = os.Stdout
-               cmd.Stderr = os.Stderr
-           } else {
-               cmd.Stdout = io.Discard
-               cmd.Stderr = io.Discard
-           }
-           err := cmd.Run()
-           if err == nil {
-               break
+   if found {
+       if err := reuseFile(indexed.FilePath, outPath+"."+format); err == nil {
+           // The earlier file carries the tags of the release it was
+           // downloaded for. Retagging replaces the hard link with a copy.
+           if err := tagFile(ctx, outPath+"."+format, track); err != nil {
+               fmt.Fprintf(os.Stderr, "Warning: failed to tag %s: %v\n", filepath.Base(outPath+"."+format), err)
            }
-           lastErr = err
+           return outPath + "." + format, OutcomeReused, nil
        }
+   }
 
-       expectedPath := filepath.Join(outDir, baseName+".mp3")
-       if _, err := os.Stat(expectedPath); err == nil {
-           return expectedPath, nil
+   existing := partialFiles(outPath)
+   path, err := fetchAudio(ctx, downloader, track, format, outPath, policy == OverwriteReplace, debug, onProgress)
+   if err != nil {
+       for file := range partialFiles(outPath) {
+           if !existing[file] {
+               os.Remove(file)
+           }
        }
+       if ctx.Err() != nil {
+           return "", "", fmt.Errorf("download cancelled: %w", context.Cause(ctx))
+       }
+       return "", "", err
+   }
+   if err := tagFile(ctx, path, track); err != nil {
+       fmt.Fprintf(os.Stderr, "Warning: failed to tag %s: %v\n", filepath.Base(path), err)
+   }
+   index.Record(track, path)
+   return path, OutcomeDownloaded, nil
+}
 
-       if lastErr != nil {
-           if strings.Contains(fmt.Sprint(lastErr), "Requested format is not available") ||
-              strings.Contains(fmt.Sprint(lastErr), "Signature extraction failed") {
-               return "", fmt.Errorf("download failed - yt-dlp is likely outdated. Please update: yt-dlp -U or brew upgrade yt-dlp")
-           }
-           return "", fmt.Errorf("all download attempts failed (try --debug for details): %w", lastErr)
+var partialSuffixPattern = regexp.MustCompile(`^\.[A-Za-z0-9.-]+$`)
+
+// partialFiles lists the files yt-dlp and ffmpeg may create for outPath:
+// the final file plus .part, .temp, .ytdl and thumbnail files.
+func partialFiles(outPath string) map[string]bool {
+   files := make(map[string]bool)
+   entries, err := os.ReadDir(filepath.Dir(outPath))
+   if err != nil {
+       return files
+   }
+   base := filepath.Base(outPath)
+   for _, e := range entries {
+       if rest, ok := strings.CutPrefix(e.Name(), base); ok && partialSuffixPattern.MatchString(rest) {
+           files[filepath.Join(filepath.Dir(outPath), e.Name())] = true
        }
-       return "", fmt.Errorf("audio file was not created - download may have failed")
+   }
+   return files
+}
+
+// describeOutcome is the message printed after a single-track download.
+func describeOutcome(outcome DownloadOutcome, path string) string {
+   switch outcome {
+   case OutcomeSkipped:
+       return fmt.Sprintf("Already downloaded: %s", path)
+   case OutcomeReused:
+       return fmt.Sprintf("Done. Copied earlier download to %s", path)
+   default:
+       return fmt.Sprintf("Done. Saved to %s", path)
+   }
+}
+
+// fetchAudio downloads track to outPath plus the format's extension using the
+// audio sources selected by -source. Existing files are only replaced when
+// overwrite is set. Download progress is passed to onProgress.
+func fetchAudio(ctx context.Context, downloader *Downloader, track SearchResult, format, outPath string, overwrite, debug bool, onProgress ProgressFunc) (string, error) {
+   req := AudioRequest{
+       Track:      track,
+       OutPath:    outPath,
+       Overwrite:  overwrite,
+       Debug:      debug,
+       OnProgress: onProgress,
+   }
+
+   switch strings.ToLower(format) {
+   case "mp3":
+       req.Format = "mp3"
+       return downloader.Fetch(ctx, req)
    case "mp4":
        if _, err := exec.LookPath("ffmpeg"); err != nil {
            return "", fmt.Errorf("ffmpeg not found in PATH. Please install it: brew install ffmpeg (macOS) or see README")
@@ -87,69 +162,25 @@ func DownloadTrack(song, artist, artworkURL, format, outDir string, debug bool)
        }
        defer os.RemoveAll(tempDir)
        artPath := filepath.Join(tempDir, "cover.jpg")
-       if err := downloadFile(artPath, artworkURL); err != nil {
+       if err := downloadFile(ctx, artPath, track.ArtworkURL); err != nil {
            return "", fmt.Errorf("failed to download artwork: %w", err)
        }
-       audioTemplate := filepath.Join(tempDir, "temp_audio.%(ext)s")
-       searchQueries := []string{
-           fmt.Sprintf("ytsearch1:%s %s lyrics", song, artist),
-           fmt.Sprintf("ytsearch1:%s %s topic", song, artist),
-           fmt.Sprintf("ytsearch1:%s %s", song, artist),
-       }
 
-       var lastErr error
-       for _, query := range searchQueries {
-           args := []string{
-               query,
-               "--extract-audio",
-               "--audio-format", "m4a",
-               "--audio-quality", "192K",
-               "--output", audioTemplate,
-               "--no-check-certificates",
-               "--no-playlist",
-               "--no-warnings",
-               "--ignore-errors",
-           }
-           cmd := exec.Command("yt-dlp", args...)
-           if debug {
-               fmt.Printf("Trying search: %s\n", query)
-               cmd.Stdout = os.Stdout
-               cmd.Stderr = os.Stderr
-           } else {
-               cmd.Stdout = io.Discard
-               cmd.Stderr = io.Discard
-           }
-           err := cmd.Run()
-           if err == nil {
-               break
-           }
-           lastErr = err
-       }
-
-       entries, err := os.ReadDir(tempDir)
+       req.Format = "m4a"
+       req.OutPath = filepath.Join(tempDir, "temp_audio")
+       req.Overwrite = false
+       audioFile, err := downloader.Fetch(ctx, req)
        if err != nil {
-           return "", fmt.Errorf("failed to read temp dir: %w", err)
+           return "", err
        }
-       var audioFile string
-       for _, e := range entries {
-           if strings.HasPrefix(e.Name(), "temp_audio") && !strings.HasSuffix(e.Name(), ".jpg") && !strings.HasSuffix(e.Name(), ".png") && !strings.HasSuffix(e.Name(), ".webp") {
-               audioFile = filepath.Join(tempDir, e.Name())
-               break
-           }
-       }
-       if audioFile == "" {
-           if lastErr != nil {
-               if strings.Contains(fmt.Sprint(lastErr), "Requested format is not available") ||
-                  strings.Contains(fmt.Sprint(lastErr), "Signature extraction failed") {
-                   return "", fmt.Errorf("download failed - yt-dlp is likely outdated. Please update: yt-dlp -U or brew upgrade yt-dlp")
-               }
-               return "", fmt.Errorf("audio download failed (try --debug for details): %w", lastErr)
-           }
-           return "", fmt.Errorf("audio extraction failed - no audio file was created. This may indicate yt-dlp needs updating")
+
+       videoPath := outPath + ".mp4"
+       overwriteArg := "-n"
+       if overwrite {
+           overwriteArg = "-y"
        }
-       outPath := filepath.Join(outDir, baseName+".mp4")
        ffArgs := []string{
-           "-y",
+           overwriteArg,
            "-loop", "1",
            "-framerate", "1",
            "-i", artPath,
@@ -162,12 +193,12 @@ func DownloadTrack(song, artist, artworkURL, format, outDir string, debug bool)
            "-pix_fmt", "yuv420p",
            "-shortest",
            "-movflags", "+faststart",
-           outPath,
+           videoPath,
        }
-       ff := exec.Command("ffmpeg", ffArgs...)
+       ff := commandContext(ctx, "ffmpeg", ffArgs...)
        if debug {
-           ff.Stdout = os.Stdout
-           ff.Stderr = os.Stderr
+           ff.Stdout = ui()
+           ff.Stderr = ui()
        } else {
            ff.Stdout = io.Discard
            ff.Stderr = io.Discard
@@ -175,14 +206,18 @@ func DownloadTrack(song, artist, artworkURL, format, outDir string, debug bool)
        if err := ff.Run(); err != nil {
            return "", fmt.Errorf("video creation failed with ffmpeg. Ensure ffmpeg is properly installed: %w", err)
        }
-       return outPath, nil
+       return videoPath, nil
    default:
        return "", fmt.Errorf("unsupported format: %s", format)
    }
 }
 
-func downloadFile(path, url string) error {
-   resp, err := http.Get(url)
+func downloadFile(ctx context.Context, path, url string) error {
+   req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+   if err != nil {
+       return err
+   }
+   resp, err := http.DefaultClient.Do(req)
    if err != nil {
        return err
    }
@@ -199,11 +234,6 @@ func downloadFile(path, url string) error {
    return err
 }
 
-func sanitizeFileName(name string) string {
-   invalid := regexp.MustCompile(`[\\/:*?"<>|]`)
-   return invalid.ReplaceAllString(name, "_")
-}
-
 func checkYtDlpVersion(ytdlpPath string, debug bool) error {
    if !debug {
        return nil
@@ -212,6 +242,6 @@ func checkYtDlpVersion(ytdlpPath string, debug bool) error {
    if err != nil {
        return nil
    }
-   fmt.Printf("yt-dlp version: %s\n", strings.TrimSpace(string(output)))
+   fmt.Fprintf(ui(), "yt-dlp version: %s\n", strings.TrimSpace(string(output)))
    return nil
 }
\ No newline at end of file
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OverwritePolicy decides what happens when a track's output file already
+// exists.
+type OverwritePolicy string
+
+const (
+	OverwriteSkip    OverwritePolicy = "skip"
+	OverwriteReplace OverwritePolicy = "overwrite"
+	OverwriteRename  OverwritePolicy = "rename"
+)
+
+var (
+	overwriteFlag = flag.String("overwrite", "", "When the output file exists: skip, overwrite or rename (default: config overwrite, or skip)")
+	noIndexFlag   = flag.Bool("no-index", false, "Don't reuse or record tracks in the download index")
+)
+
+// registerDownloadFlags makes the overwrite, download index, audio source and
+// file naming flags available on a subcommand's flag set.
+func registerDownloadFlags(fs *flag.FlagSet) {
+	fs.StringVar(overwriteFlag, "overwrite", *overwriteFlag, "When the output file exists: skip, overwrite or rename")
+	fs.BoolVar(noIndexFlag, "no-index", *noIndexFlag, "Don't reuse or record tracks in the download index")
+	fs.StringVar(sourceFlag, "source", *sourceFlag, "Where to get audio: youtube, soundcloud, bandcamp, local or command; comma separated to fall back in order")
+	fs.StringVar(nameTemplateFlag, "name-template", *nameTemplateFlag, "Name downloaded files with a named or inline template, e.g. \"{albumartist}/{year} - {album}/{track:02} {title}\"")
+}
+
+// overwritePolicy combines -overwrite with the default from config.json.
+func overwritePolicy() (OverwritePolicy, error) {
+	policy := *overwriteFlag
+	if policy == "" {
+		if config, err := LoadConfig(); err == nil {
+			policy = config.Overwrite
+		}
+	}
+
+	switch p := OverwritePolicy(strings.ToLower(policy)); p {
+	case "":
+		return OverwriteSkip, nil
+	case OverwriteSkip, OverwriteReplace, OverwriteRename:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid overwrite policy %q (use skip, overwrite or rename)", policy)
+	}
+}
+
+// checkDownloadFlags reports invalid -overwrite, -source and -name-template
+// values before any work is done. It returns the name template and the audio
+// downloader to pass to DownloadTrack.
+func checkDownloadFlags() (string, *Downloader, error) {
+	if _, err := overwritePolicy(); err != nil {
+		return "", nil, err
+	}
+	template, err := nameTemplate()
+	if err != nil {
+		return "", nil, err
+	}
+	downloader, err := audioDownloader()
+	if err != nil {
+		return "", nil, err
+	}
+	return template, downloader, nil
+}
+
+// uniqueOutputPath returns outPath, or "outPath (n)" for the first n whose
+// file doesn't exist yet.
+func uniqueOutputPath(outPath, ext string) string {
+	candidate := outPath
+	for n := 2; ; n++ {
+		if _, err := os.Stat(candidate + "." + ext); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s (%d)", outPath, n)
+	}
+}
+
+// reuseFile hard links src to dst, copying when linking isn't possible
+// (e.g. across filesystems).
+func reuseFile(src, dst string) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+type IndexEntry struct {
+	ID           string    `json:"id"`
+	ISRC         string    `json:"isrc,omitempty"`
+	Name         string    `json:"name"`
+	Artist       string    `json:"artist"`
+	FilePath     string    `json:"file_path"`
+	DownloadedAt time.Time `json:"downloaded_at"`
+}
+
+// DownloadIndex remembers which Apple Music tracks have been downloaded and
+// where, so a song that shows up again on another album or playlist is
+// copied instead of fetched twice. Tracks match by Apple Music ID or ISRC.
+type DownloadIndex struct {
+	path    string
+	mu      sync.Mutex
+	Entries []IndexEntry `json:"entries"`
+}
+
+func GetDownloadIndexPath() (string, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), "downloads.json"), nil
+}
+
+func LoadDownloadIndex(path string) (*DownloadIndex, error) {
+	index := &DownloadIndex{path: path}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read download index: %w", err)
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse download index: %w", err)
+	}
+	return index, nil
+}
+
+var (
+	sharedIndex     *DownloadIndex
+	sharedIndexOnce sync.Once
+)
+
+// downloadIndex returns the index shared by all downloads, or nil when it is
+// disabled with -no-index or can't be loaded.
+func downloadIndex() *DownloadIndex {
+	if *noIndexFlag {
+		return nil
+	}
+	sharedIndexOnce.Do(func() {
+		path, err := GetDownloadIndexPath()
+		if err != nil {
+			return
+		}
+		index, err := LoadDownloadIndex(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: download index disabled: %v\n", err)
+			return
+		}
+		sharedIndex = index
+	})
+	return sharedIndex
+}
+
+func (e IndexEntry) matches(track SearchResult) bool {
+	return (track.ID != "" && e.ID == track.ID) || (track.ISRC != "" && e.ISRC == track.ISRC)
+}
+
+// isAt reports whether the entry's file is path.
+func (e IndexEntry) isAt(path string) bool {
+	abs, err := filepath.Abs(path)
+	return err == nil && abs == e.FilePath
+}
+
+// Lookup finds an earlier download of track in the given format whose file
+// still exists.
+func (idx *DownloadIndex) Lookup(track SearchResult, format string) (IndexEntry, bool) {
+	if idx == nil {
+		return IndexEntry{}, false
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, entry := range idx.Entries {
+		if !entry.matches(track) || !strings.EqualFold(filepath.Ext(entry.FilePath), "."+format) {
+			continue
+		}
+		if _, err := os.Stat(entry.FilePath); err == nil {
+			return entry, true
+		}
+	}
+	return IndexEntry{}, false
+}
+
+// Record adds or updates the entry for track and writes the index to disk.
+func (idx *DownloadIndex) Record(track SearchResult, filePath string) {
+	if idx == nil || track.ID == "" {
+		return
+	}
+	if abs, err := filepath.Abs(filePath); err == nil {
+		filePath = abs
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entry := IndexEntry{
+		ID:           track.ID,
+		ISRC:         track.ISRC,
+		Name:         track.Name,
+		Artist:       track.ArtistName,
+		FilePath:     filePath,
+		DownloadedAt: time.Now(),
+	}
+	replaced := false
+	for i, existing := range idx.Entries {
+		if existing.ID == entry.ID && strings.EqualFold(filepath.Ext(existing.FilePath), filepath.Ext(filePath)) {
+			idx.Entries[i] = entry
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		idx.Entries = append(idx.Entries, entry)
+	}
+
+	if err := idx.save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to update download index: %v\n", err)
+	}
+}
+
+func (idx *DownloadIndex) save() error {
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := idx.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, idx.path)
+}
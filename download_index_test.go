@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadIndexLookup(t *testing.T) {
+	dir := t.TempDir()
+	index, err := LoadDownloadIndex(filepath.Join(dir, "downloads.json"))
+	if err != nil {
+		t.Fatalf("LoadDownloadIndex returned an unexpected error: %v", err)
+	}
+
+	file := filepath.Join(dir, "The Beatles - Something.mp3")
+	if err := os.WriteFile(file, []byte("audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	index.Record(SearchResult{ID: "1441164430", ISRC: "GBAYE0601696", Name: "Something", ArtistName: "The Beatles"}, file)
+
+	reloaded, err := LoadDownloadIndex(filepath.Join(dir, "downloads.json"))
+	if err != nil {
+		t.Fatalf("LoadDownloadIndex returned an unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		track  SearchResult
+		format string
+		want   bool
+	}{
+		{"same ID", SearchResult{ID: "1441164430"}, "mp3", true},
+		{"same ISRC on another album", SearchResult{ID: "999", ISRC: "GBAYE0601696"}, "mp3", true},
+		{"other format", SearchResult{ID: "1441164430"}, "mp4", false},
+		{"other track", SearchResult{ID: "1", ISRC: "USUM71900001"}, "mp3", false},
+	}
+	for _, tt := range tests {
+		if _, ok := reloaded.Lookup(tt.track, tt.format); ok != tt.want {
+			t.Errorf("%s: Lookup() found = %t; want %t", tt.name, ok, tt.want)
+		}
+	}
+
+	entry, _ := reloaded.Lookup(SearchResult{ID: "1441164430"}, "mp3")
+	if !entry.isAt(file) || entry.isAt(filepath.Join(dir, "The Beatles - Something (2).mp3")) {
+		t.Errorf("entry at %q: isAt() doesn't match only %q", entry.FilePath, file)
+	}
+
+	os.Remove(file)
+	if _, ok := reloaded.Lookup(SearchResult{ID: "1441164430"}, "mp3"); ok {
+		t.Error("Lookup() returned an entry whose file was deleted")
+	}
+}
+
+func TestUniqueOutputPath(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "Artist - Song")
+	if got := uniqueOutputPath(base, "mp3"); got != base {
+		t.Errorf("uniqueOutputPath() = %q; want %q", got, base)
+	}
+
+	for _, name := range []string{"Artist - Song.mp3", "Artist - Song (2).mp3"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := uniqueOutputPath(base, "mp3"), base+" (3)"; got != want {
+		t.Errorf("uniqueOutputPath() = %q; want %q", got, want)
+	}
+}
+
+func TestOverwritePolicy(t *testing.T) {
+	defer func(old string) { *overwriteFlag = old }(*overwriteFlag)
+
+	*overwriteFlag = "Rename"
+	if policy, err := overwritePolicy(); err != nil || policy != OverwriteRename {
+		t.Errorf("overwritePolicy() = %q, %v; want rename", policy, err)
+	}
+
+	*overwriteFlag = "clobber"
+	if _, err := overwritePolicy(); err == nil {
+		t.Error("overwritePolicy() accepted an unknown policy")
+	}
+}
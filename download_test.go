@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestPartialFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"The Beatles - Something.mp3",
+		"The Beatles - Something.mp3.part",
+		"The Beatles - Something.temp.mp3",
+		"The Beatles - Something.webm.ytdl",
+		"The Beatles - Something (2).mp3",
+		"The Beatles - Something Else.mp3",
+		"cover.jpg",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := partialFiles(filepath.Join(dir, "The Beatles - Something"))
+	for _, name := range []string{
+		"The Beatles - Something.mp3",
+		"The Beatles - Something.mp3.part",
+		"The Beatles - Something.temp.mp3",
+		"The Beatles - Something.webm.ytdl",
+	} {
+		if !files[filepath.Join(dir, name)] {
+			t.Errorf("partialFiles() is missing %q", name)
+		}
+	}
+	if len(files) != 4 {
+		t.Errorf("partialFiles() = %v; want 4 files", files)
+	}
+}
+
+func TestDownloadTrackRetagsReusedFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	defer func(old string) { *overwriteFlag = old }(*overwriteFlag)
+	*overwriteFlag = "skip"
+	sharedIndexOnce = sync.Once{}
+	defer func() { sharedIndexOnce, sharedIndex = sync.Once{}, nil }()
+
+	dir := t.TempDir()
+	first := SearchResult{ID: "1", ISRC: "USABC2000001", Name: "Song", ArtistName: "Artist", AlbumName: "Single", TrackNumber: 1, TrackCount: 1}
+	firstPath := filepath.Join(dir, "Single.mp3")
+	if err := os.WriteFile(firstPath, []byte("audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTags(firstPath, NewTrackTags(first)); err != nil {
+		t.Fatal(err)
+	}
+	downloadIndex().Record(first, firstPath)
+
+	second := first
+	second.ID, second.AlbumName, second.TrackNumber, second.TrackCount = "2", "Album", 4, 10
+	path, outcome, err := DownloadTrack(context.Background(), second, "mp3", dir, "{album}", nil, false, nil)
+	if err != nil || outcome != OutcomeReused {
+		t.Fatalf("DownloadTrack() = %q, %q, %v; want a reused file", path, outcome, err)
+	}
+
+	for file, want := range map[string][2]string{
+		firstPath: {"Single", "1/1"},
+		path:      {"Album", "4/10"},
+	} {
+		frames := readID3Frames(t, file)
+		if got := frames["TALB"]; got != "\x03"+want[0] {
+			t.Errorf("%s: TALB = %q; want %q", filepath.Base(file), got, want[0])
+		}
+		if got := frames["TRCK"]; got != "\x03"+want[1] {
+			t.Errorf("%s: TRCK = %q; want %q", filepath.Base(file), got, want[1])
+		}
+	}
+}
+
+// readID3Frames returns the text of the ID3v2.4 frames in the file at path.
+func readID3Frames(t *testing.T, path string) map[string]string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) < 10 || string(data[:3]) != "ID3" {
+		t.Fatalf("%s has no ID3 tag", path)
+	}
+	size := int(decodeSyncsafe(data[6:10]))
+	frames := make(map[string]string)
+	for rest := data[10 : 10+size]; len(rest) >= 10 && rest[0] != 0; {
+		n := int(decodeSyncsafe(rest[4:8]))
+		frames[string(rest[:4])] = string(rest[10 : 10+n])
+		rest = rest[10+n:]
+	}
+	return frames
+}
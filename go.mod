@@ -7,9 +7,12 @@ toolchain go1.24.1
 require (
 	github.com/atotto/clipboard v0.1.4
 	github.com/guitaripod/musickitkat v0.0.3
+	golang.org/x/term v0.34.0
+	golang.org/x/text v0.28.0
 )
 
 require (
 	github.com/golang-jwt/jwt/v5 v5.2.1 // indirect
 	golang.org/x/oauth2 v0.28.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
 )
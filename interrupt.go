@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// errInterrupted is returned by commands that stopped early because of
+// Ctrl-C, after they have cleaned up and reported what they did.
+var errInterrupted = errors.New("interrupted")
+
+// interruptContext returns a context that is cancelled with errInterrupted
+// by the first Ctrl-C or SIGTERM. Signal handling is then reset, so a second
+// Ctrl-C exits immediately.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+			signal.Stop(signals)
+			fmt.Fprintln(ui(), "\nInterrupted, stopping... (press Ctrl-C again to quit immediately)")
+			cancel(errInterrupted)
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel(context.Canceled)
+	}
+}
+
+// wasInterrupted reports whether ctx, or the interruptContext it derives
+// from, was cancelled by a signal.
+func wasInterrupted(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errInterrupted)
+}
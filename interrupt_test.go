@@ -0,0 +1,35 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestInterruptContext(t *testing.T) {
+	ctx, stop := interruptContext()
+	defer stop()
+	child, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-child.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context wasn't cancelled by SIGINT")
+	}
+	if !wasInterrupted(ctx) || !wasInterrupted(child) {
+		t.Errorf("wasInterrupted() = %t, %t for the context and its child; want true", wasInterrupted(ctx), wasInterrupted(child))
+	}
+
+	ctx, stop = interruptContext()
+	stop()
+	if wasInterrupted(ctx) {
+		t.Error("wasInterrupted() = true after stop; want false")
+	}
+}
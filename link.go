@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/atotto/clipboard"
+)
+
+// LinkResolution is the outcome of resolving a single input URL.
+type LinkResolution struct {
+	URL    string
+	Result *LinksOutput
+	Err    error
+}
+
+// ResolveLinks resolves every URL through song.link using up to concurrency
+// parallel requests. Results are returned in input order.
+func ResolveLinks(ctx context.Context, urls []string, concurrency int) []LinkResolution {
+	return resolveAll(ctx, urls, concurrency, ResolveLink)
+}
+
+func resolveAll(ctx context.Context, urls []string, concurrency int, resolve func(context.Context, string) (*LinksOutput, error)) []LinkResolution {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	resolutions := make([]LinkResolution, len(urls))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				result, err := resolve(ctx, urls[idx])
+				resolutions[idx] = LinkResolution{URL: urls[idx], Result: result, Err: err}
+			}
+		}()
+	}
+
+	for i := range urls {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return resolutions
+}
+
+// readURLs reads newline separated URLs, skipping blank lines and lines
+// starting with #.
+func readURLs(r io.Reader) ([]string, error) {
+	var urls []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading URLs: %w", err)
+	}
+	return urls, nil
+}
+
+// registerOutputFlags makes the global output flags available on a
+// subcommand's flag set.
+func registerOutputFlags(fs *flag.FlagSet) {
+	fs.BoolVar(xFlag, "x", *xFlag, "Return the song.link URL without surrounding <>")
+	fs.BoolVar(dFlag, "d", *dFlag, "Return the song.link URL surrounded by <> and the Spotify URL")
+	fs.BoolVar(sFlag, "s", *sFlag, "Return only the Spotify URL")
+	fs.Var(&platformFlag, "platform", "Return the URL for a platform (repeatable)")
+	fs.StringVar(templateFlag, "template", *templateFlag, "Format the output with a named or inline template")
+	fs.BoolVar(jsonFlag, "json", *jsonFlag, "Print results as JSON")
+}
+
+func executeLink(args []string) error {
+	linkCmd := flag.NewFlagSet("link", flag.ExitOnError)
+	copyFlag := linkCmd.Bool("copy", false, "Copy the output to the clipboard")
+	concurrentFlag := linkCmd.Int("concurrent", 4, "Number of URLs to resolve in parallel")
+	registerOutputFlags(linkCmd)
+	registerCacheFlag(linkCmd)
+	registerLookupFlags(linkCmd)
+	helpFlag := linkCmd.Bool("help", false, "Show help for link command")
+	hFlag := linkCmd.Bool("h", false, "Show help for link command")
+
+	if err := linkCmd.Parse(reorderArgs(args, map[string]bool{"concurrent": true, "platform": true, "template": true, "country": true})); err != nil {
+		return err
+	}
+
+	if *helpFlag || *hFlag {
+		printLinkHelp()
+		os.Exit(0)
+	}
+
+	var urls []string
+	for _, arg := range linkCmd.Args() {
+		if arg != "-" {
+			urls = append(urls, arg)
+		}
+	}
+	if len(urls) == 0 || contains(linkCmd.Args(), "-") {
+		if len(urls) == 0 && isTerminal(os.Stdin) {
+			return fmt.Errorf("no URLs given: pass them as arguments or pipe them on stdin")
+		}
+		stdinURLs, err := readURLs(os.Stdin)
+		if err != nil {
+			return err
+		}
+		urls = append(urls, stdinURLs...)
+	}
+	if len(urls) == 0 {
+		return fmt.Errorf("no URLs given")
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+	return resolveAndPrint(ctx, urls, *concurrentFlag, *copyFlag)
+}
+
+func resolveAndPrint(ctx context.Context, urls []string, concurrency int, copyOutput bool) error {
+	resolutions := ResolveLinks(ctx, urls, concurrency)
+
+	var outputs []string
+	var results []*LinksOutput
+	failed := 0
+	for _, r := range resolutions {
+		if r.Err != nil {
+			failed++
+			results = append(results, &LinksOutput{URL: r.URL, Error: r.Err.Error()})
+			if !*jsonFlag {
+				fmt.Fprintf(os.Stderr, "❌ %s: %v\n", r.URL, r.Err)
+			}
+			continue
+		}
+		results = append(results, r.Result)
+		outputs = append(outputs, r.Result.Output)
+		if !*jsonFlag {
+			fmt.Println(r.Result.Output)
+		}
+	}
+
+	if copyOutput && len(outputs) > 0 {
+		if err := clipboard.WriteAll(strings.Join(outputs, "\n")); err != nil {
+			return fmt.Errorf("error copying output string to clipboard: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "Copied to the clipboard")
+	}
+
+	if *jsonFlag {
+		if err := printJSON(results); err != nil {
+			return err
+		}
+	}
+
+	if wasInterrupted(ctx) {
+		return errInterrupted
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d URLs could not be resolved", failed, len(urls))
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
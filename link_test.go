@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResolveLinksKeepsInputOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Query().Get("url"), "https://music.apple.com/us/song/")
+		if id == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"pageUrl": "https://song.link/i/%s", "linksByPlatform": {}}`, id)
+	}))
+	defer server.Close()
+
+	original := songlinkAPIBase
+	songlinkAPIBase = server.URL
+	defer func() { songlinkAPIBase = original }()
+
+	urls := []string{
+		"https://music.apple.com/us/song/1",
+		"https://music.apple.com/us/song/missing",
+		"https://music.apple.com/us/song/3",
+		"https://music.apple.com/us/song/4",
+	}
+	resolutions := ResolveLinks(context.Background(), urls, 3)
+
+	if len(resolutions) != len(urls) {
+		t.Fatalf("ResolveLinks returned %d results; want %d", len(resolutions), len(urls))
+	}
+	for i, r := range resolutions {
+		if r.URL != urls[i] {
+			t.Errorf("result %d has URL %q; want %q", i, r.URL, urls[i])
+		}
+	}
+	if resolutions[1].Err == nil {
+		t.Errorf("expected an error for %q", urls[1])
+	}
+	if got := resolutions[3].Result.Output; got != "https://song.link/i/4" {
+		t.Errorf("result 3 output = %q; want %q", got, "https://song.link/i/4")
+	}
+}
+
+func TestReadURLs(t *testing.T) {
+	input := "https://a.example\n\n# comment\n  https://b.example  \n"
+	urls, err := readURLs(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("readURLs returned an unexpected error: %v", err)
+	}
+	if len(urls) != 2 || urls[0] != "https://a.example" || urls[1] != "https://b.example" {
+		t.Errorf("readURLs() = %q; want [https://a.example https://b.example]", urls)
+	}
+}
@@ -2,9 +2,12 @@ package main
 
 import (
    "context"
+   "errors"
    "flag"
    "fmt"
    "os"
+   "path/filepath"
+   "sort"
    "strings"
    "sync"
    "time"
@@ -16,10 +19,18 @@ var (
 	xFlag = flag.Bool("x", false, "Return the song.link URL without surrounding <>")
 	dFlag = flag.Bool("d", false, "Return the song.link URL surrounded by <> and the Spotify URL")
 	sFlag = flag.Bool("s", false, "Return only the Spotify URL")
+	templateFlag = flag.String("template", "", "Format the output with a named template from config or an inline Go template")
+	jsonFlag = flag.Bool("json", false, "Print machine-readable JSON on stdout instead of human text")
 	hFlag = flag.Bool("h", false, "Show help information")
 	helpFlag = flag.Bool("help", false, "Show help information")
+
+	platformFlag platformList
 )
 
+func init() {
+	flag.Var(&platformFlag, "platform", "Return the URL for a platform (repeatable, e.g. -platform tidal -platform youtubeMusic)")
+}
+
 type Command struct {
 	Name        string
 	Description string
@@ -27,11 +38,36 @@ type Command struct {
 }
 
 var commands = []Command{
+	{
+		Name:        "link",
+		Description: "Get shareable links for URLs given as arguments or on stdin",
+		Execute:     executeLink,
+	},
+	{
+		Name:        "convert",
+		Description: "Convert a file of URLs into a CSV or JSON of platform links",
+		Execute:     executeConvert,
+	},
+	{
+		Name:        "watch",
+		Description: "Watch the clipboard and convert copied music links automatically",
+		Execute:     executeWatch,
+	},
 	{
 		Name:        "search",
 		Description: "Search for a song or album and get its links",
 		Execute:     executeSearch,
 	},
+   {
+       Name:        "artist",
+       Description: "Download an artist's discography from an Apple Music artist URL",
+       Execute:     executeArtist,
+   },
+   {
+       Name:        "cache",
+       Description: "Clear or inspect the on-disk response cache",
+       Execute:     executeCache,
+   },
    {
        Name:        "config",
        Description: "Configure Apple Music API credentials",
@@ -65,8 +101,7 @@ func main() {
 			if cmd.Name == subcommand {
 				err := cmd.Execute(args[1:])
 				if err != nil {
-					fmt.Println("An error occurred:", err)
-					os.Exit(1)
+					exitWithError(err)
 				}
 				return
 			}
@@ -77,16 +112,28 @@ func main() {
 			os.Exit(0)
 		}
 		
-		fmt.Printf("Unknown command: %s\n\n", subcommand)
+		fmt.Fprintf(ui(), "Unknown command: %s\n\n", subcommand)
 		printHelp("")
 		os.Exit(1)
 	}
 
 	err := runDefault()
 	if err != nil {
+		exitWithError(err)
+	}
+}
+
+func exitWithError(err error) {
+	if errors.Is(err, errInterrupted) {
+		fmt.Fprintln(ui(), "Interrupted.")
+		os.Exit(130)
+	}
+	if *jsonFlag {
+		printJSON(ErrorOutput{Error: err.Error()})
+	} else {
 		fmt.Println("An error occurred:", err)
-		os.Exit(1)
 	}
+	os.Exit(1)
 }
 
 func reorderArgs(args []string, valueFlags map[string]bool) []string {
@@ -114,8 +161,12 @@ func executeSearch(args []string) error {
    debugFlag := searchCmd.Bool("debug", false, "Enable debug logging during download")
    helpFlag := searchCmd.Bool("help", false, "Show help for search command")
    hFlag := searchCmd.Bool("h", false, "Show help for search command")
+   searchCmd.BoolVar(jsonFlag, "json", *jsonFlag, "Print the selected result as JSON")
+   registerCacheFlag(searchCmd)
+   registerDownloadFlags(searchCmd)
+   registerLookupFlags(searchCmd)
 
-	if err := searchCmd.Parse(reorderArgs(args, map[string]bool{"type": true, "out": true})); err != nil {
+	if err := searchCmd.Parse(reorderArgs(args, map[string]bool{"type": true, "out": true, "country": true, "overwrite": true, "source": true, "name-template": true})); err != nil {
 		return err
 	}
 
@@ -124,6 +175,11 @@ func executeSearch(args []string) error {
 		os.Exit(0)
 	}
 
+	template, downloader, err := checkDownloadFlags()
+	if err != nil {
+		return err
+	}
+
 	searchArgs := searchCmd.Args()
 	if len(searchArgs) == 0 {
 		return fmt.Errorf("search query required")
@@ -141,7 +197,7 @@ func executeSearch(args []string) error {
 		searchType = Both
 	}
 	
-   return HandleSearch(query, searchType, *outFlag, *debugFlag)
+   return HandleSearch(query, searchType, *outFlag, template, downloader, *debugFlag)
 }
 
 func executeConfig(args []string) error {
@@ -158,7 +214,7 @@ func executeConfig(args []string) error {
 		os.Exit(0)
 	}
 	
-	fmt.Println("Configuring Apple Music API credentials...")
+	fmt.Fprintln(ui(), "Configuring Apple Music API credentials...")
    return RunOnboarding()
 }
 
@@ -170,8 +226,11 @@ func executeDownload(args []string) error {
    debugFlag := downloadCmd.Bool("debug", false, "Enable debug logging (show yt-dlp/ffmpeg output)")
    helpFlag := downloadCmd.Bool("help", false, "Show help for download command")
    hFlag := downloadCmd.Bool("h", false, "Show help for download command")
+   downloadCmd.BoolVar(jsonFlag, "json", *jsonFlag, "Print the download result as JSON")
+   registerCacheFlag(downloadCmd)
+   registerDownloadFlags(downloadCmd)
 
-   if err := downloadCmd.Parse(reorderArgs(args, map[string]bool{"type": true, "format": true, "out": true})); err != nil {
+   if err := downloadCmd.Parse(reorderArgs(args, map[string]bool{"type": true, "format": true, "out": true, "overwrite": true, "source": true, "name-template": true})); err != nil {
        return err
    }
 
@@ -180,12 +239,29 @@ func executeDownload(args []string) error {
        os.Exit(0)
    }
 
+   template, downloader, err := checkDownloadFlags()
+   if err != nil {
+       return err
+   }
+
    queryArgs := downloadCmd.Args()
    if len(queryArgs) == 0 {
        return fmt.Errorf("download query required")
    }
    query := strings.Join(queryArgs, " ")
 
+   if len(queryArgs) == 1 && isHTTPURL(query) {
+       return downloadURL(query, batchOptions{
+           Format:       *formatFlag,
+           OutputDir:    *outFlag,
+           NameTemplate: template,
+           Downloader:   downloader,
+           Concurrency:  defaultConcurrency,
+           Debug:        *debugFlag,
+           TrackTimeout: defaultTrackTimeout,
+       })
+   }
+
    var searchType SearchType
    switch *typeFlag {
    case "song":
@@ -201,7 +277,7 @@ func executeDownload(args []string) error {
        return fmt.Errorf("error loading config: %w", err)
    }
    if !config.ConfigExists {
-       fmt.Println("Apple Music API credentials not found. Let's set them up.")
+       fmt.Fprintln(ui(), "Apple Music API credentials not found. Let's set them up.")
        if err := RunOnboarding(); err != nil {
            return fmt.Errorf("error during onboarding: %w", err)
        }
@@ -227,28 +303,115 @@ func executeDownload(args []string) error {
    if err != nil {
        return fmt.Errorf("error selecting result: %w", err)
    }
-   fmt.Printf("\nSelected: %s - %s\n", selected.Name, selected.ArtistName)
+   fmt.Fprintf(ui(), "\nSelected: %s - %s\n", selected.Name, selected.ArtistName)
+
+   line := NewProgressLine("Downloading... ", *debugFlag)
+   start := time.Now()
+   dlCtx, stop := interruptContext()
+   defer stop()
+   path, outcome, err := DownloadTrack(dlCtx, *selected, *formatFlag, *outFlag, template, downloader, *debugFlag, line.Update)
+   if wasInterrupted(dlCtx) {
+       return errInterrupted
+   }
+   if err != nil {
+       line.Done("failed")
+       return fmt.Errorf("download error: %w", err)
+   }
+   line.Done(describeOutcome(outcome, path))
+
+   if *jsonFlag {
+       return printJSON(DownloadOutput{
+           Query:    query,
+           Selected: selected,
+           FileOutput: FileOutput{
+               FilePath:        path,
+               Format:          *formatFlag,
+               Outcome:         outcome,
+               DurationSeconds: time.Since(start).Seconds(),
+           },
+       })
+   }
+   return nil
+}
 
-   fmt.Print("Downloading... ")
-   path, err := DownloadTrack(selected.Name, selected.ArtistName, selected.ArtworkURL, *formatFlag, *outFlag, *debugFlag)
+// downloadURL downloads the song behind a music URL from any service
+// song.link supports, downloading albums, playlists and artists the way the
+// playlist and artist commands do with their default settings.
+func downloadURL(musicURL string, opts batchOptions) error {
+   dlCtx, stop := interruptContext()
+   defer stop()
+   resource, err := resolveMusicURL(dlCtx, musicURL)
    if err != nil {
+       return err
+   }
+   switch resource.Type {
+   case ParsedArtist:
+       kinds, err := ParseReleaseKinds(defaultReleaseKinds)
+       if err != nil {
+           return err
+       }
+       return downloadArtist(dlCtx, musicURL, resource, artistOptions{batchOptions: opts, Filter: DiscographyFilter{Kinds: kinds}})
+   case ParsedAlbum, ParsedPlaylist:
+       return downloadPlaylist(dlCtx, musicURL, resource, playlistOptions{batchOptions: opts})
+   }
+   fetchCtx, cancel := context.WithTimeout(dlCtx, 30*time.Second)
+   defer cancel()
+   tracks, _, err := fetchCollectionTracks(fetchCtx, musicURL, resource)
+   if wasInterrupted(dlCtx) {
+       return errInterrupted
+   }
+   if err != nil {
+       return err
+   }
+   selected := tracks[0]
+
+   line := NewProgressLine("Downloading... ", opts.Debug)
+   start := time.Now()
+   path, outcome, err := DownloadTrack(dlCtx, selected, opts.Format, opts.OutputDir, opts.NameTemplate, opts.Downloader, opts.Debug, line.Update)
+   if wasInterrupted(dlCtx) {
+       return errInterrupted
+   }
+   if err != nil {
+       line.Done("failed")
        return fmt.Errorf("download error: %w", err)
    }
-   fmt.Printf("Done. Saved to %s\n", path)
+   line.Done(describeOutcome(outcome, path))
+
+   if *jsonFlag {
+       return printJSON(DownloadOutput{
+           Query:    musicURL,
+           Selected: &selected,
+           FileOutput: FileOutput{
+               FilePath:        path,
+               Format:          opts.Format,
+               Outcome:         outcome,
+               DurationSeconds: time.Since(start).Seconds(),
+           },
+       })
+   }
    return nil
 }
 
 func executePlaylist(args []string) error {
+	if len(args) > 0 && args[0] == "links" {
+		return executePlaylistLinks(args[1:])
+	}
+
 	playlistCmd := flag.NewFlagSet("playlist", flag.ExitOnError)
 	formatFlag := playlistCmd.String("format", "mp3", "Download format: mp3 or mp4 (default: mp3)")
 	outFlag := playlistCmd.String("out", "downloads", "Output directory for downloaded files")
-	concurrentFlag := playlistCmd.Int("concurrent", 3, "Number of parallel downloads (default: 3)")
+	concurrentFlag := playlistCmd.Int("concurrent", defaultConcurrency, "Number of parallel downloads (default: 3)")
 	metadataFlag := playlistCmd.Bool("metadata", false, "Save playlist metadata JSON")
+	resumeFlag := playlistCmd.String("resume", "", "Resume the download recorded in this metadata JSON file")
+	trackTimeoutFlag := playlistCmd.Duration("track-timeout", defaultTrackTimeout, "Give up on a download attempt after this long (0 = no limit)")
 	debugFlag := playlistCmd.Bool("debug", false, "Enable debug logging")
 	helpFlag := playlistCmd.Bool("help", false, "Show help for playlist command")
 	hFlag := playlistCmd.Bool("h", false, "Show help for playlist command")
+	playlistCmd.BoolVar(jsonFlag, "json", *jsonFlag, "Print download results as JSON")
+	registerCacheFlag(playlistCmd)
+	registerDownloadFlags(playlistCmd)
 
-	if err := playlistCmd.Parse(reorderArgs(args, map[string]bool{"format": true, "out": true, "concurrent": true})); err != nil {
+	if err := playlistCmd.Parse(reorderArgs(args, map[string]bool{"format": true, "out": true, "concurrent": true, "resume": true, "track-timeout": true, "overwrite": true, "source": true, "name-template": true})); err != nil {
 		return err
 	}
 	
@@ -257,138 +420,396 @@ func executePlaylist(args []string) error {
 		os.Exit(0)
 	}
 
-	urlArgs := playlistCmd.Args()
-	if len(urlArgs) == 0 {
-		return fmt.Errorf("Apple Music URL required")
+	template, downloader, err := checkDownloadFlags()
+	if err != nil {
+		return err
 	}
-	musicURL := urlArgs[0]
 
+	var saved *PlaylistMetadata
+	if *resumeFlag != "" {
+		var err error
+		saved, err = LoadPlaylistMetadata(*resumeFlag)
+		if err != nil {
+			return err
+		}
+		if !flagWasSet(playlistCmd, "out") {
+			*outFlag = filepath.Dir(*resumeFlag)
+		}
+	}
+
+	var musicURL string
+	switch {
+	case playlistCmd.NArg() > 0:
+		musicURL = playlistCmd.Arg(0)
+	case saved != nil && saved.SourceURL != "":
+		musicURL = saved.SourceURL
+	default:
+		return fmt.Errorf("music URL required")
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	resource, err := resolveMusicURL(ctx, musicURL)
+	if err != nil {
+		return err
+	}
+	return downloadPlaylist(ctx, musicURL, resource, playlistOptions{
+		batchOptions: batchOptions{
+			Format:       *formatFlag,
+			OutputDir:    *outFlag,
+			NameTemplate: template,
+			Downloader:   downloader,
+			Concurrency:  *concurrentFlag,
+			Debug:        *debugFlag,
+			SaveMetadata: *metadataFlag,
+			TrackTimeout: *trackTimeoutFlag,
+		},
+		Resume:     saved,
+		ResumePath: *resumeFlag,
+	})
+}
+
+// playlistOptions configures downloadPlaylist. Resume is the metadata read
+// from ResumePath for --resume; without it, metadata left in OutputDir by an
+// earlier run is resumed.
+type playlistOptions struct {
+	batchOptions
+	Resume     *PlaylistMetadata
+	ResumePath string
+}
+
+// downloadPlaylist downloads the tracks of the album, playlist or song that
+// musicURL resolved to.
+func downloadPlaylist(ctx context.Context, musicURL string, resource *ParsedResource, opts playlistOptions) error {
+	fetchCtx, cancel := context.WithTimeout(ctx, catalogFetchTimeout)
+	tracks, metadata, err := fetchCollectionTracks(fetchCtx, musicURL, resource)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	saved := opts.Resume
+	metadataPath := MetadataFilePath(metadata, opts.OutputDir)
+	if saved != nil {
+		if saved.ID != metadata.ID {
+			return fmt.Errorf("%s belongs to %s %q, not %s", opts.ResumePath, saved.Type, saved.Name, musicURL)
+		}
+		metadataPath = opts.ResumePath
+	} else if existing, err := LoadPlaylistMetadata(metadataPath); err == nil && existing.ID == metadata.ID {
+		saved = existing
+	}
+
+	saveMetadata := opts.SaveMetadata
+	if saved != nil {
+		resumed := metadata.ResumeFrom(saved)
+		fmt.Fprintf(ui(), "Resuming from %s: %d of %d tracks already downloaded\n", metadataPath, resumed, len(tracks))
+		saveMetadata = true
+	}
+
+	if saveMetadata {
+		if err := WritePlaylistMetadata(metadata, metadataPath); err != nil {
+			fmt.Fprintf(ui(), "Warning: Failed to save metadata: %v\n", err)
+		}
+	}
+
+	opts.SaveMetadata = saveMetadata
+	opts.MetadataPath = metadataPath
+	opts.Skip = metadata.DownloadedTrackIDs()
+	results, progress := runBatchDownload(ctx, tracks, metadata, opts.batchOptions)
+	progress.PrintSummary()
+
+	if *jsonFlag {
+		if err := printJSON(NewPlaylistOutput(metadata, musicURL, results, progress)); err != nil {
+			return err
+		}
+	}
+	if wasInterrupted(ctx) {
+		return errInterrupted
+	}
+	return nil
+}
+
+func loadConfigWithOnboarding() (*Config, error) {
 	config, err := LoadConfig()
 	if err != nil {
-		return fmt.Errorf("error loading config: %w", err)
+		return nil, fmt.Errorf("error loading config: %w", err)
 	}
 	if !config.ConfigExists {
-		fmt.Println("Apple Music API credentials not found. Let's set them up.")
+		fmt.Fprintln(ui(), "Apple Music API credentials not found. Let's set them up.")
 		if err := RunOnboarding(); err != nil {
-			return fmt.Errorf("error during onboarding: %w", err)
+			return nil, fmt.Errorf("error during onboarding: %w", err)
 		}
 		config, err = LoadConfig()
 		if err != nil {
-			return fmt.Errorf("error loading config after onboarding: %w", err)
+			return nil, fmt.Errorf("error loading config after onboarding: %w", err)
 		}
 	}
+	return config, nil
+}
+
+const (
+	// catalogFetchTimeout bounds the Apple Music requests that list a
+	// collection's tracks. Downloads themselves have no overall deadline.
+	catalogFetchTimeout = 2 * time.Minute
+	defaultTrackTimeout = 10 * time.Minute
+	defaultConcurrency  = 3
+)
+
+// resolveMusicURL resolves musicURL to an Apple Music resource, returning
+// errInterrupted when ctx was interrupted meanwhile.
+func resolveMusicURL(ctx context.Context, musicURL string) (*ParsedResource, error) {
+	resource, err := NewPlaylistURLParser().ResolveResource(ctx, musicURL)
+	if wasInterrupted(ctx) {
+		return nil, errInterrupted
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	return resource, nil
+}
 
-	parser := NewPlaylistURLParser()
-	resource, err := parser.Parse(musicURL)
+// fetchCollectionTracks loads the album or playlist that musicURL resolved
+// to together with the metadata describing it.
+func fetchCollectionTracks(ctx context.Context, musicURL string, resource *ParsedResource) ([]SearchResult, *PlaylistMetadata, error) {
+	config, err := loadConfigWithOnboarding()
 	if err != nil {
-		return fmt.Errorf("invalid URL: %w", err)
+		return nil, nil, err
 	}
 
-	fmt.Printf("Detected %s from %s storefront\n", resource.Type, resource.Storefront)
+	fmt.Fprintf(ui(), "Detected %s from %s storefront\n", resource.Type, resource.Storefront)
 
 	searcher, err := NewExtendedMusicSearcher(config)
 	if err != nil {
-		return fmt.Errorf("error creating music searcher: %w", err)
+		return nil, nil, fmt.Errorf("error creating music searcher: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-	defer cancel()
-
 	var tracks []SearchResult
 	var metadata *PlaylistMetadata
 
 	switch resource.Type {
 	case ParsedAlbum:
-		fmt.Printf("Fetching album details...\n")
+		fmt.Fprintf(ui(), "Fetching album details...\n")
 		album, err := searcher.GetAlbumWithTracks(ctx, resource.ID, resource.Storefront)
 		if err != nil {
-			return fmt.Errorf("error fetching album: %w", err)
+			return nil, nil, fmt.Errorf("error fetching album: %w", err)
 		}
 		tracks = album.Tracks
 		metadata = CreateAlbumMetadata(album, musicURL)
-		fmt.Printf("Album: %s - %s (%d tracks)\n", album.Name, album.ArtistName, len(tracks))
+		fmt.Fprintf(ui(), "Album: %s - %s (%d tracks)\n", album.Name, album.ArtistName, len(tracks))
 
 	case ParsedPlaylist:
-		fmt.Printf("Fetching playlist details...\n")
+		fmt.Fprintf(ui(), "Fetching playlist details...\n")
 		playlist, err := searcher.GetPlaylistWithTracks(ctx, resource.ID, resource.Storefront)
 		if err != nil {
-			return fmt.Errorf("error fetching playlist: %w", err)
+			return nil, nil, fmt.Errorf("error fetching playlist: %w", err)
 		}
 		tracks = playlist.Tracks
 		metadata = CreatePlaylistMetadata(playlist, musicURL)
-		fmt.Printf("Playlist: %s by %s (%d tracks)\n", playlist.Name, playlist.CuratorName, len(tracks))
+		fmt.Fprintf(ui(), "Playlist: %s by %s (%d tracks)\n", playlist.Name, playlist.CuratorName, len(tracks))
+
+	case ParsedSong:
+		fmt.Fprintf(ui(), "Fetching song details...\n")
+		song, err := searcher.GetSong(ctx, resource.ID, resource.Storefront)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error fetching song: %w", err)
+		}
+		tracks = []SearchResult{*song}
+		metadata = CreateSongMetadata(song, musicURL)
+		fmt.Fprintf(ui(), "Song: %s - %s\n", song.Name, song.ArtistName)
+
+	case ParsedMusicVideo:
+		fmt.Fprintf(ui(), "Fetching music video details...\n")
+		video, err := searcher.GetMusicVideo(ctx, resource.ID, resource.Storefront)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error fetching music video: %w", err)
+		}
+		tracks = []SearchResult{*video}
+		metadata = CreateSongMetadata(video, musicURL)
+		fmt.Fprintf(ui(), "Music video: %s - %s\n", video.Name, video.ArtistName)
+
+	case ParsedArtist:
+		return nil, nil, fmt.Errorf("artist URLs point to a whole discography; use 'songlink-cli artist' instead")
 	}
 
 	if len(tracks) == 0 {
-		return fmt.Errorf("no tracks found")
+		return nil, nil, fmt.Errorf("no tracks found")
 	}
 
-	if err := os.MkdirAll(*outFlag, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
-	}
+	return tracks, metadata, nil
+}
 
-	if *metadataFlag {
-		if err := SavePlaylistMetadata(metadata, *outFlag); err != nil {
-			fmt.Printf("Warning: Failed to save metadata: %v\n", err)
+// flagWasSet reports whether name was given on the command line rather than
+// left at its default.
+func flagWasSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
 		}
-	}
+	})
+	return set
+}
 
-	downloader := NewBatchDownloader(*concurrentFlag)
+type batchOptions struct {
+	Format       string
+	OutputDir    string
+	NameTemplate string
+	Downloader   *Downloader
+	Concurrency  int
+	Debug        bool
+	SaveMetadata bool
+	// MetadataPath is where metadata is saved; by default the metadata
+	// file in OutputDir.
+	MetadataPath string
+	Skip         map[string]bool
+	TrackTimeout time.Duration
+}
+
+// runBatchDownload downloads tracks into opts.OutputDir, printing a line per
+// finished track and keeping metadata up to date when opts.SaveMetadata is
+// set. Tracks whose ID is in opts.Skip are left out. Jobs are fed to the
+// workers as they free up while results are reported, so the queue never
+// holds more than a few tracks; tracks that can't be queued before ctx is
+// done are reported as cancelled. On a terminal a ProgressBoard below the
+// result lines shows the tracks in flight. Results are returned in track order.
+func runBatchDownload(ctx context.Context, tracks []SearchResult, metadata *PlaylistMetadata, opts batchOptions) ([]DownloadResult, *ProgressTracker) {
+	if opts.SaveMetadata && metadata != nil && opts.MetadataPath == "" {
+		opts.MetadataPath = MetadataFilePath(metadata, opts.OutputDir)
+	}
+	downloader := NewBatchDownloader(opts.Concurrency)
 	downloader.Start(ctx)
 
-	fmt.Printf("\nQueuing %d tracks for download...\n", len(tracks))
-	for i, track := range tracks {
-		job := DownloadJob{
-			Track:     track,
-			Format:    *formatFlag,
-			OutputDir: *outFlag,
-			Debug:     *debugFlag,
-			Index:     i + 1,
+	board := NewProgressBoard(downloader.GetProgress(), len(tracks)-len(opts.Skip), opts.Debug)
+	var results []DownloadResult
+	report := func(result DownloadResult) {
+		results = append(results, result)
+		var line string
+		switch {
+		case result.Outcome == OutcomeCancelled:
+			line = fmt.Sprintf("🚫 [%d/%d] Cancelled: %s - %s",
+				result.Job.Index, len(tracks),
+				result.Job.Track.ArtistName, result.Job.Track.Name)
+		case result.Error != nil:
+			line = fmt.Sprintf("❌ [%d/%d] Failed: %s - %s (%v)",
+				result.Job.Index, len(tracks),
+				result.Job.Track.ArtistName, result.Job.Track.Name,
+				result.Error)
+		case result.Outcome == OutcomeSkipped:
+			line = fmt.Sprintf("⏭️  [%d/%d] Already downloaded: %s - %s",
+				result.Job.Index, len(tracks),
+				result.Job.Track.ArtistName, result.Job.Track.Name)
+		case result.Outcome == OutcomeReused:
+			line = fmt.Sprintf("♻️  [%d/%d] Copied earlier download: %s - %s",
+				result.Job.Index, len(tracks),
+				result.Job.Track.ArtistName, result.Job.Track.Name)
+		default:
+			line = fmt.Sprintf("✅ [%d/%d] Downloaded: %s - %s",
+				result.Job.Index, len(tracks),
+				result.Job.Track.ArtistName, result.Job.Track.Name)
 		}
-		if err := downloader.QueueDownload(job); err != nil {
-			fmt.Printf("Failed to queue track %d: %v\n", i+1, err)
+		if board != nil {
+			board.Println(line)
+		} else {
+			fmt.Fprintln(ui(), line)
+		}
+
+		if opts.SaveMetadata && metadata != nil {
+			metadata.UpdateTrackStatus(result.Job.Track.ID,
+				result.Error == nil, result.FilePath, result.Error)
+			WritePlaylistMetadata(metadata, opts.MetadataPath)
 		}
 	}
 
-	fmt.Printf("Starting downloads with %d workers...\n\n", *concurrentFlag)
-	
 	done := make(chan bool)
 	go func() {
 		for result := range downloader.GetResults() {
-			if result.Error != nil {
-				fmt.Printf("❌ [%d/%d] Failed: %s - %s (%v)\n", 
-					result.Job.Index, len(tracks),
-					result.Job.Track.ArtistName, result.Job.Track.Name, 
-					result.Error)
-			} else {
-				fmt.Printf("✅ [%d/%d] Downloaded: %s - %s\n", 
-					result.Job.Index, len(tracks),
-					result.Job.Track.ArtistName, result.Job.Track.Name)
-			}
-
-			if *metadataFlag && metadata != nil {
-				metadata.UpdateTrackStatus(result.Job.Track.ID, 
-					result.Error == nil, result.FilePath, result.Error)
-				SavePlaylistMetadata(metadata, *outFlag)
-			}
+			report(result)
 		}
 		done <- true
 	}()
 
+	fmt.Fprintf(ui(), "\nDownloading %d tracks with %d workers...\n\n", len(tracks)-len(opts.Skip), opts.Concurrency)
+	if board != nil {
+		board.Start()
+	}
+
+	var unqueued []DownloadResult
+	for i, track := range tracks {
+		if opts.Skip[track.ID] {
+			continue
+		}
+		job := DownloadJob{
+			Track:        track,
+			Format:       opts.Format,
+			OutputDir:    opts.OutputDir,
+			NameTemplate: opts.NameTemplate,
+			Downloader:   opts.Downloader,
+			Debug:        opts.Debug,
+			Timeout:      opts.TrackTimeout,
+			Index:        i + 1,
+		}
+		if err := downloader.QueueDownload(ctx, job); err != nil {
+			unqueued = append(unqueued, DownloadResult{Job: job, Outcome: OutcomeCancelled, Error: err})
+		}
+	}
+
 	downloader.Close()
 	<-done
 
-	downloader.GetProgress().PrintSummary()
+	for _, result := range unqueued {
+		report(result)
+	}
+	if board != nil {
+		board.Stop()
+	}
+
+	if opts.SaveMetadata && metadata != nil {
+		if err := WritePlaylistMetadata(metadata, opts.MetadataPath); err != nil {
+			fmt.Fprintf(ui(), "Warning: Failed to save metadata: %v\n", err)
+		}
+	}
 
-	return nil
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Job.Index < results[j].Job.Index
+	})
+	return results, downloader.GetProgress()
 }
 
 func runDefault() error {
+	ctx, stop := interruptContext()
+	defer stop()
+
+	if hasPipedInput(os.Stdin) {
+		urls, err := readURLs(os.Stdin)
+		if err != nil {
+			return err
+		}
+		if len(urls) > 0 {
+			return resolveAndPrint(ctx, urls, 4, false)
+		}
+	}
+
 	searchURL, err := clipboard.ReadAll()
 	if err != nil {
 		return fmt.Errorf("error reading clipboard: %w", err)
 	}
 
+	if *jsonFlag {
+		result, err := GetLinks(ctx, searchURL)
+		if wasInterrupted(ctx) {
+			return errInterrupted
+		}
+		if err != nil {
+			return fmt.Errorf("error getting links: %w", err)
+		}
+		return printJSON(result)
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(1)
 	stopLoading := make(chan bool)
@@ -397,7 +818,10 @@ func runDefault() error {
 		loadingIndicator(stopLoading)
 	}()
 
-	err = GetLinks(searchURL)
+	_, err = GetLinks(ctx, searchURL)
+	if wasInterrupted(ctx) {
+		return errInterrupted
+	}
 	if err != nil {
 		return fmt.Errorf("error getting links: %w", err)
 	}
@@ -410,14 +834,24 @@ func runDefault() error {
 
 func printHelp(command string) {
 	switch command {
+	case "link":
+		printLinkHelp()
+	case "convert":
+		printConvertHelp()
+	case "watch":
+		printWatchHelp()
 	case "search":
 		printSearchHelp()
 	case "download":
 		printDownloadHelp()
 	case "playlist":
 		printPlaylistHelp()
+	case "artist":
+		printArtistHelp()
 	case "config":
 		printConfigHelp()
+	case "cache":
+		printCacheHelp()
 	default:
 		printGeneralHelp()
 	}
@@ -427,23 +861,43 @@ func printGeneralHelp() {
 	fmt.Println("Songlink CLI - A powerful tool for music sharing and downloading")
 	fmt.Println("")
 	fmt.Println("USAGE:")
-	fmt.Println("  songlink-cli [flags]                    Process URL from clipboard")
+	fmt.Println("  songlink-cli [flags]                    Process URL from clipboard (or stdin)")
 	fmt.Println("  songlink-cli <command> [flags] <args>   Run a specific command")
 	fmt.Println("  songlink-cli help <command>             Show help for a command")
 	fmt.Println("")
 	fmt.Println("COMMANDS:")
+	fmt.Println("  link       Get shareable links for URLs from arguments or stdin")
+	fmt.Println("  convert    Convert a file of URLs into CSV/JSON with every platform link")
+	fmt.Println("  watch      Convert music links on the clipboard as they are copied")
 	fmt.Println("  search     Search for songs/albums and get shareable links")
 	fmt.Println("  download   Search and download tracks as MP3 or MP4 files")
 	fmt.Println("  playlist   Download entire playlists or albums from Apple Music")
+	fmt.Println("  artist     Download an artist's discography from Apple Music")
 	fmt.Println("  config     Configure Apple Music API credentials")
+	fmt.Println("  cache      Clear or inspect the response cache")
 	fmt.Println("")
 	fmt.Println("GLOBAL FLAGS:")
 	fmt.Println("  -h, --help   Show this help message")
+	fmt.Println("  -json        Print machine-readable JSON on stdout (no spinner, no")
+	fmt.Println("               clipboard); prompts and progress go to stderr")
+	fmt.Println("  -no-cache    Bypass the on-disk song.link/Apple Music response cache")
 	fmt.Println("")
 	fmt.Println("URL PROCESSING FLAGS (when run without command):")
 	fmt.Println("  -x   Return song.link URL without <> brackets (for Twitter)")
 	fmt.Println("  -d   Return song.link URL with <> + Spotify URL (for Discord)")
 	fmt.Println("  -s   Return only the Spotify URL")
+	fmt.Println("  -platform=<name>")
+	fmt.Println("       Return the URL for a platform; repeat or comma separate for")
+	fmt.Println("       several (spotify, appleMusic, youtube, youtubeMusic, tidal,")
+	fmt.Println("       deezer, amazonMusic, soundcloud, bandcamp, ...)")
+	fmt.Println("  -country=<cc>")
+	fmt.Println("       Resolve links in a country's catalog, e.g. -country=DE (default:")
+	fmt.Println("       \"country\" from config.json)")
+	fmt.Println("  -song-if-single")
+	fmt.Println("       Resolve a single's album link to the song itself")
+	fmt.Println("  -template=<name|text>")
+	fmt.Println("       Format the output with a named template (slack, markdown, irc,")
+	fmt.Println("       mastodon or one from config.json) or an inline Go template")
 	fmt.Println("")
 	fmt.Println("EXAMPLES:")
 	fmt.Println("  # Process URL from clipboard (default)")
@@ -452,6 +906,16 @@ func printGeneralHelp() {
 	fmt.Println("  # Get link for Twitter sharing")
 	fmt.Println("  songlink-cli -x")
 	fmt.Println("")
+	fmt.Println("  # Get Tidal and YouTube Music links")
+	fmt.Println("  songlink-cli -platform=tidal -platform=youtubeMusic")
+	fmt.Println("")
+	fmt.Println("  # Share as a Markdown link")
+	fmt.Println("  songlink-cli -template=markdown")
+	fmt.Println("")
+	fmt.Println("  # Resolve URLs without using the clipboard")
+	fmt.Println("  songlink-cli link -d \"https://music.apple.com/...\"")
+	fmt.Println("  cat urls.txt | songlink-cli link -platform=tidal")
+	fmt.Println("")
 	fmt.Println("  # Search for a song")
 	fmt.Println("  songlink-cli search \"Bohemian Rhapsody\"")
 	fmt.Println("")
@@ -465,6 +929,91 @@ func printGeneralHelp() {
 	fmt.Println("  songlink-cli help <command>")
 }
 
+func printLinkHelp() {
+	fmt.Println("songlink-cli link - Get shareable links for one or more URLs")
+	fmt.Println("")
+	fmt.Println("USAGE:")
+	fmt.Println("  songlink-cli link [flags] <url>...")
+	fmt.Println("  <urls> | songlink-cli link [flags]")
+	fmt.Println("")
+	fmt.Println("DESCRIPTION:")
+	fmt.Println("  Resolve music URLs through song.link without reading the clipboard,")
+	fmt.Println("  which makes it usable on headless machines and in scripts. URLs are")
+	fmt.Println("  taken from the arguments, or from stdin (one per line) when no")
+	fmt.Println("  arguments are given or one of them is \"-\". URLs are resolved")
+	fmt.Println("  concurrently and printed in input order.")
+	fmt.Println("")
+	fmt.Println("FLAGS:")
+	fmt.Println("  -copy             Also copy the output to the clipboard")
+	fmt.Println("  -concurrent=<n>   URLs resolved in parallel (default: 4)")
+	fmt.Println("  -x, -d, -s        Output format, as in the default mode")
+	fmt.Println("  -platform=<p>     Return the URL for a platform (repeatable)")
+	fmt.Println("  -template=<t>     Format the output with a template")
+	fmt.Println("  -country=<cc>     Resolve links in a country's catalog")
+	fmt.Println("  -song-if-single   Resolve a single's album link to the song")
+	fmt.Println("  -json             Print an array of results as JSON")
+	fmt.Println("")
+	fmt.Println("EXAMPLES:")
+	fmt.Println("  songlink-cli link \"https://music.apple.com/us/album/abbey-road/401469823\"")
+	fmt.Println("  songlink-cli link -platform=tidal url1 url2")
+	fmt.Println("  cat urls.txt | songlink-cli link -json")
+}
+
+func printConvertHelp() {
+	fmt.Println("songlink-cli convert - Bulk convert a file of URLs")
+	fmt.Println("")
+	fmt.Println("USAGE:")
+	fmt.Println("  songlink-cli convert [flags] <file>...")
+	fmt.Println("")
+	fmt.Println("DESCRIPTION:")
+	fmt.Println("  Reads URLs from text files (one per line) or CSV files (the first")
+	fmt.Println("  column containing URLs), resolves them through song.link in")
+	fmt.Println("  parallel and writes the original URL, song.link page URL, title,")
+	fmt.Println("  artist and every platform link. URLs that could not be resolved")
+	fmt.Println("  are kept with the reason in the error column. Use - to read stdin.")
+	fmt.Println("")
+	fmt.Println("FLAGS:")
+	fmt.Println("  -out=<file>       Output file (default: stdout)")
+	fmt.Println("  -format=<fmt>     csv or json (default: from -out extension, else csv)")
+	fmt.Println("  -concurrent=<n>   URLs resolved in parallel (default: 4)")
+	fmt.Println("  -country=<cc>     Resolve links in a country's catalog")
+	fmt.Println("  -song-if-single   Resolve a single's album link to the song")
+	fmt.Println("  -no-cache         Bypass the response cache")
+	fmt.Println("")
+	fmt.Println("EXAMPLES:")
+	fmt.Println("  songlink-cli convert -out=links.csv apple-music-urls.txt")
+	fmt.Println("  songlink-cli convert -out=links.json library.csv")
+	fmt.Println("")
+	fmt.Println("NOTE:")
+	fmt.Println("  song.link allows 10 requests per minute without an API key; large")
+	fmt.Println("  files are throttled accordingly (see songlink-cli help config).")
+}
+
+func printWatchHelp() {
+	fmt.Println("songlink-cli watch - Convert copied music links automatically")
+	fmt.Println("")
+	fmt.Println("USAGE:")
+	fmt.Println("  songlink-cli watch [flags]")
+	fmt.Println("")
+	fmt.Println("DESCRIPTION:")
+	fmt.Println("  Polls the clipboard and, whenever a new Apple Music, Spotify, YouTube,")
+	fmt.Println("  Tidal or Deezer URL is copied, replaces it with the formatted share")
+	fmt.Println("  text. Links that were already converted are served from memory")
+	fmt.Println("  instead of calling song.link again. Stop with Ctrl-C.")
+	fmt.Println("")
+	fmt.Println("FLAGS:")
+	fmt.Println("  -interval=<dur>   How often to check the clipboard (default: 500ms)")
+	fmt.Println("  -debounce=<dur>   How long a URL must stay copied before it is")
+	fmt.Println("                    converted (default: 1s)")
+	fmt.Println("  -x, -d, -s        Output format, as in the default mode")
+	fmt.Println("  -platform=<p>     Return the URL for a platform (repeatable)")
+	fmt.Println("  -template=<t>     Format the output with a template")
+	fmt.Println("")
+	fmt.Println("EXAMPLES:")
+	fmt.Println("  songlink-cli watch -d")
+	fmt.Println("  songlink-cli watch -template=markdown")
+}
+
 func printSearchHelp() {
 	fmt.Println("songlink-cli search - Search for songs or albums and get shareable links")
 	fmt.Println("")
@@ -482,11 +1031,23 @@ func printSearchHelp() {
 	fmt.Println("  -type=<type>   Search type: song, album, or both (default: song)")
 	fmt.Println("  -out=<dir>     Output directory for downloads (default: downloads)")
 	fmt.Println("  -debug         Enable debug logging during download")
+	fmt.Println("  -overwrite=<p> If the file exists: skip, overwrite or rename")
+	fmt.Println("                 (default: skip)")
+	fmt.Println("  -no-index      Don't reuse earlier downloads of the same track")
+	fmt.Println("  -source=<list> Audio sources to try in order: youtube, soundcloud,")
+	fmt.Println("                 bandcamp, local, command (default: youtube)")
+	fmt.Println("  -name-template=<t>")
+	fmt.Println("                 Name files with a named (flat, library) or inline")
+	fmt.Println("                 template (default: \"{artist} - {title}\")")
+	fmt.Println("  -json          Print the selected result and links/download as JSON")
 	fmt.Println("")
 	fmt.Println("GLOBAL FLAGS (when copying links):")
 	fmt.Println("  -x             Format link for Twitter (no brackets)")
 	fmt.Println("  -d             Format for Discord (with Spotify URL)")
 	fmt.Println("  -s             Copy only Spotify URL")
+	fmt.Println("  -platform=<p>  Copy the URL for a platform (repeatable)")
+	fmt.Println("  -template=<t>  Format the copied text with a template")
+	fmt.Println("  -country=<cc>  Resolve links in a country's catalog")
 	fmt.Println("")
 	fmt.Println("EXAMPLES:")
 	fmt.Println("  # Search for a song")
@@ -508,17 +1069,35 @@ func printDownloadHelp() {
 	fmt.Println("")
 	fmt.Println("USAGE:")
 	fmt.Println("  songlink-cli download [flags] <query>")
+	fmt.Println("  songlink-cli download [flags] <music-url>")
 	fmt.Println("")
 	fmt.Println("DESCRIPTION:")
 	fmt.Println("  Search for a song or album and download it immediately as an audio")
 	fmt.Println("  file (MP3) or video file with album artwork (MP4). This command")
 	fmt.Println("  combines search and download into a single step.")
 	fmt.Println("")
+	fmt.Println("  Instead of a query you can pass a track URL from Apple Music, Spotify,")
+	fmt.Println("  YouTube Music, Tidal, Deezer or any other service song.link supports.")
+	fmt.Println("  Album URLs are downloaded in full, like the playlist command.")
+	fmt.Println("")
+	fmt.Println("  Files are tagged with the Apple Music title, artist, album, album")
+	fmt.Println("  artist, track and disc number, release date, genre, ISRC, composer")
+	fmt.Println("  and full-resolution artwork (ID3v2.4 for MP3, ilst atoms for MP4).")
+	fmt.Println("")
 	fmt.Println("FLAGS:")
 	fmt.Println("  -type=<type>     Search type: song or album (default: song)")
 	fmt.Println("  -format=<fmt>    Download format: mp3 or mp4 (default: mp3)")
 	fmt.Println("  -out=<dir>       Output directory (default: downloads)")
 	fmt.Println("  -debug           Show yt-dlp and ffmpeg output")
+	fmt.Println("  -overwrite=<p>   If the file exists: skip, overwrite or rename")
+	fmt.Println("                   (default: skip)")
+	fmt.Println("  -no-index        Don't reuse earlier downloads of the same track")
+	fmt.Println("  -source=<list>   Audio sources to try in order: youtube, soundcloud,")
+	fmt.Println("                   bandcamp, local, command (default: youtube)")
+	fmt.Println("  -name-template=<t>")
+	fmt.Println("                   Name files with a named (flat, library) or inline")
+	fmt.Println("                   template (default: \"{artist} - {title}\")")
+	fmt.Println("  -json            Print the selected result and file path as JSON")
 	fmt.Println("")
 	fmt.Println("EXAMPLES:")
 	fmt.Println("  # Download a song as MP3")
@@ -530,6 +1109,9 @@ func printDownloadHelp() {
 	fmt.Println("  # Download to custom directory")
 	fmt.Println("  songlink-cli download -out=~/Music \"Yesterday\"")
 	fmt.Println("")
+	fmt.Println("  # Download a track shared from Spotify")
+	fmt.Println("  songlink-cli download \"https://open.spotify.com/track/...\"")
+	fmt.Println("")
 	fmt.Println("REQUIREMENTS:")
 	fmt.Println("  - Apple Music API credentials (run 'songlink-cli config' to set up)")
 	fmt.Println("  - yt-dlp: For downloading audio from YouTube")
@@ -543,15 +1125,25 @@ func printPlaylistHelp() {
 	fmt.Println("songlink-cli playlist - Download entire playlists or albums")
 	fmt.Println("")
 	fmt.Println("USAGE:")
-	fmt.Println("  songlink-cli playlist [flags] <apple-music-url>")
+	fmt.Println("  songlink-cli playlist [flags] <music-url>")
+	fmt.Println("  songlink-cli playlist links [flags] <music-url>")
 	fmt.Println("")
 	fmt.Println("DESCRIPTION:")
 	fmt.Println("  Download all tracks from an Apple Music playlist or album URL.")
 	fmt.Println("  Supports parallel downloads and automatic retry on failures.")
 	fmt.Println("")
+	fmt.Println("  Album and track URLs from Spotify, YouTube Music, Tidal, Deezer and")
+	fmt.Println("  other services are resolved to Apple Music through song.link first.")
+	fmt.Println("")
+	fmt.Println("  With \"links\", nothing is downloaded: every track is resolved")
+	fmt.Println("  through song.link and a shareable list of per-platform links is")
+	fmt.Println("  written instead.")
+	fmt.Println("")
 	fmt.Println("SUPPORTED CONTENT:")
 	fmt.Println("  ✓ Public catalog albums")
 	fmt.Println("  ✓ Public catalog playlists")
+	fmt.Println("  ✓ Single songs and music videos (/song/..., /album/...?i=..., /music-video/...)")
+	fmt.Println("  ✓ Albums and tracks from other services (via song.link)")
 	fmt.Println("  ✗ Personal library playlists")
 	fmt.Println("  ✗ User-created playlists")
 	fmt.Println("  ✗ Radio stations")
@@ -561,22 +1153,53 @@ func printPlaylistHelp() {
 	fmt.Println("  --out=<dir>         Output directory (default: downloads)")
 	fmt.Println("  --concurrent=<n>    Parallel downloads, 1-10 (default: 3)")
 	fmt.Println("  --metadata          Save playlist/album info as JSON")
+	fmt.Println("  --resume=<file>     Resume from a metadata JSON file; the URL and --out")
+	fmt.Println("                      default to its source URL and directory")
+	fmt.Println("  --track-timeout=<d> Give up on a download attempt after this long,")
+	fmt.Println("                      e.g. 90s or 15m; 0 for no limit (default: 10m)")
 	fmt.Println("  --debug             Show detailed progress and errors")
+	fmt.Println("  --overwrite=<p>     If a file exists: skip, overwrite or rename")
+	fmt.Println("                      (default: skip)")
+	fmt.Println("  --no-index          Don't reuse earlier downloads of the same track")
+	fmt.Println("  --source=<list>     Audio sources to try in order: youtube, soundcloud,")
+	fmt.Println("                      bandcamp, local, command (default: youtube)")
+	fmt.Println("  --name-template=<t> Name files with a named (flat, library) or inline")
+	fmt.Println("                      template (default: \"{artist} - {title}\")")
+	fmt.Println("  --json              Print per-track results and summary as JSON")
+	fmt.Println("")
+	fmt.Println("LINKS FLAGS:")
+	fmt.Println("  --format=<fmt>      markdown, csv or json (default: from --out")
+	fmt.Println("                      extension, else markdown)")
+	fmt.Println("  --out=<file>        Output file (default: stdout)")
+	fmt.Println("  --platform=<p>      Only include these platforms (repeatable)")
+	fmt.Println("  --concurrent=<n>    Tracks resolved in parallel (default: 4)")
+	fmt.Println("  --country=<cc>      Resolve links in a country's catalog")
 	fmt.Println("")
 	fmt.Println("EXAMPLES:")
 	fmt.Println("  # Download an album")
 	fmt.Println("  songlink-cli playlist \"https://music.apple.com/us/album/abbey-road/401469823\"")
 	fmt.Println("")
+	fmt.Println("  # Download an album shared from Spotify")
+	fmt.Println("  songlink-cli playlist \"https://open.spotify.com/album/...\"")
+	fmt.Println("")
 	fmt.Println("  # Download playlist with metadata")
 	fmt.Println("  songlink-cli playlist --metadata \"https://music.apple.com/playlist/...\"")
 	fmt.Println("")
+	fmt.Println("  # Pick up where an interrupted run left off")
+	fmt.Println("  songlink-cli playlist --resume=\"downloads/Abbey Road_metadata.json\"")
+	fmt.Println("")
+	fmt.Println("  # Share an album as Spotify and Tidal links")
+	fmt.Println("  songlink-cli playlist links --platform=spotify,tidal \"https://music.apple.com/...\"")
+	fmt.Println("")
 	fmt.Println("  # Fast download with 5 workers")
 	fmt.Println("  songlink-cli playlist --concurrent=5 --format=mp4 \"https://...\"")
 	fmt.Println("")
 	fmt.Println("FEATURES:")
-	fmt.Println("  - Progress tracking for each download")
+	fmt.Println("  - Live progress board with ETA (plain lines when not a terminal)")
 	fmt.Println("  - Automatic retry with exponential backoff")
 	fmt.Println("  - Saves metadata including track status")
+	fmt.Println("  - Re-runs with a metadata file in --out skip tracks already downloaded")
+	fmt.Println("  - Ctrl-C stops cleanly, removing partial files and saving metadata")
 	fmt.Println("  - Creates organized directory structure")
 	fmt.Println("")
 	fmt.Println("TROUBLESHOOTING:")
@@ -584,6 +1207,78 @@ func printPlaylistHelp() {
 	fmt.Println("              storefront in URL (e.g., /us/, /gb/, /jp/)")
 }
 
+func printArtistHelp() {
+	fmt.Println("songlink-cli artist - Download an artist's discography")
+	fmt.Println("")
+	fmt.Println("USAGE:")
+	fmt.Println("  songlink-cli artist [flags] <apple-music-artist-url>")
+	fmt.Println("")
+	fmt.Println("DESCRIPTION:")
+	fmt.Println("  Fetch every release of an artist from the Apple Music catalog and")
+	fmt.Println("  download the ones matching the filters. Each release goes into its")
+	fmt.Println("  own directory, <out>/<artist>/<year> - <album>, next to a metadata")
+	fmt.Println("  JSON file recording which tracks were downloaded.")
+	fmt.Println("")
+	fmt.Println("FLAGS:")
+	fmt.Println("  --include=<types>   Release types: albums, singles, eps, compilations")
+	fmt.Println("                      (default: albums,eps,singles)")
+	fmt.Println("  --from-year=<yyyy>  Only releases from this year on")
+	fmt.Println("  --to-year=<yyyy>    Only releases up to this year")
+	fmt.Println("  --list              List matching releases without downloading")
+	fmt.Println("  --format=<fmt>      Download format: mp3 or mp4 (default: mp3)")
+	fmt.Println("  --out=<dir>         Output directory (default: downloads)")
+	fmt.Println("  --concurrent=<n>    Parallel downloads per release (default: 3)")
+	fmt.Println("  --track-timeout=<d> Give up on a download attempt after this long,")
+	fmt.Println("                      e.g. 90s or 15m; 0 for no limit (default: 10m)")
+	fmt.Println("  --debug             Show detailed progress and errors")
+	fmt.Println("  --overwrite=<p>     If a file exists: skip, overwrite or rename")
+	fmt.Println("                      (default: skip)")
+	fmt.Println("  --no-index          Don't reuse earlier downloads of the same track")
+	fmt.Println("  --source=<list>     Audio sources to try in order: youtube, soundcloud,")
+	fmt.Println("                      bandcamp, local, command (default: youtube)")
+	fmt.Println("  --name-template=<t> Name files with a named (flat, library) or inline")
+	fmt.Println("                      template (default: \"{artist} - {title}\"). Templates")
+	fmt.Println("                      with a \"/\" are relative to --out, others to each")
+	fmt.Println("                      album's directory")
+	fmt.Println("  --json              Print releases and per-track results as JSON")
+	fmt.Println("")
+	fmt.Println("EXAMPLES:")
+	fmt.Println("  # See what would be downloaded")
+	fmt.Println("  songlink-cli artist --list \"https://music.apple.com/us/artist/the-beatles/136975\"")
+	fmt.Println("")
+	fmt.Println("  # Studio albums from the 1960s only")
+	fmt.Println("  songlink-cli artist --include=albums --from-year=1963 --to-year=1969 \"https://music.apple.com/us/artist/the-beatles/136975\"")
+	fmt.Println("")
+	fmt.Println("  # Everything, including compilations")
+	fmt.Println("  songlink-cli artist --include=albums,singles,eps,compilations --out=~/Music \"https://music.apple.com/...\"")
+	fmt.Println("")
+	fmt.Println("REQUIREMENTS:")
+	fmt.Println("  - Apple Music API credentials (run 'songlink-cli config' to set up)")
+	fmt.Println("  - yt-dlp and ffmpeg")
+}
+
+func printCacheHelp() {
+	fmt.Println("songlink-cli cache - Manage the on-disk response cache")
+	fmt.Println("")
+	fmt.Println("USAGE:")
+	fmt.Println("  songlink-cli cache clear   Remove all cached responses")
+	fmt.Println("  songlink-cli cache stats   Show the number and size of cached responses")
+	fmt.Println("")
+	fmt.Println("DESCRIPTION:")
+	fmt.Println("  song.link and Apple Music responses are cached under")
+	fmt.Println("  ~/.songlink-cli/cache so repeated shares and re-runs of the same")
+	fmt.Println("  playlist don't hit the network. Pass -no-cache to any command to")
+	fmt.Println("  bypass the cache.")
+	fmt.Println("")
+	fmt.Println("CONFIGURATION (~/.songlink-cli/config.json):")
+	fmt.Println("  \"cache\": {")
+	fmt.Println("    \"songlink_ttl\": \"168h\",     song.link entry lifetime (default: 7 days)")
+	fmt.Println("    \"apple_music_ttl\": \"24h\",   Apple Music entry lifetime (default: 1 day)")
+	fmt.Println("    \"max_size_mb\": 100          Oldest entries are evicted beyond this")
+	fmt.Println("  }")
+
+}
+
 func printConfigHelp() {
 	fmt.Println("songlink-cli config - Configure Apple Music API credentials")
 	fmt.Println("")
@@ -611,6 +1306,48 @@ func printConfigHelp() {
 	fmt.Println("STORED LOCATION:")
 	fmt.Println("  ~/.songlink-cli/config.json")
 	fmt.Println("")
+	fmt.Println("OUTPUT TEMPLATES:")
+	fmt.Println("  Named templates for -template can be added to config.json:")
+	fmt.Println("    \"templates\": {")
+	fmt.Println("      \"discord\": \"<{{.PageURL}}>\\n{{.Links.spotify}}\"")
+	fmt.Println("    }")
+	fmt.Println("  Fields: .Title .Artist .Type .Thumbnail .PageURL .Links .Platforms")
+	fmt.Println("  Functions: join, upper, lower, platformName")
+	fmt.Println("")
+	fmt.Println("LINK RESOLUTION DEFAULTS:")
+	fmt.Println("  \"country\": \"DE\",                     Default for -country")
+	fmt.Println("  \"song_if_single\": true               Default for -song-if-single")
+	fmt.Println("")
+	fmt.Println("DOWNLOAD DEFAULTS:")
+	fmt.Println("  \"overwrite\": \"rename\"                Default for -overwrite")
+	fmt.Println("  Downloaded tracks are indexed by Apple Music ID and ISRC in")
+	fmt.Println("  ~/.songlink-cli/downloads.json so repeats are copied, not fetched")
+	fmt.Println("")
+	fmt.Println("FILE NAMES:")
+	fmt.Println("  \"name_template\": \"library\",          Default for -name-template")
+	fmt.Println("  \"name_templates\": {")
+	fmt.Println("    \"tagged\": \"{albumartist}/{album}/{track:02} {title}\"")
+	fmt.Println("  }")
+	fmt.Println("  Built in: flat = \"{artist} - {title}\",")
+	fmt.Println("            library = \"{albumartist}/{year} - {album}/{disc}-{track:02} {title}\"")
+	fmt.Println("  Placeholders: {artist} {title} {album} {albumartist} {year} {date}")
+	fmt.Println("  {track} {tracks} {disc} {genre} {composer} {isrc} {id}; {track:02}")
+	fmt.Println("  pads to two digits. \"/\" starts a directory; parts left empty by")
+	fmt.Println("  missing fields are dropped.")
+	fmt.Println("")
+	fmt.Println("AUDIO SOURCES:")
+	fmt.Println("  \"sources\": {")
+	fmt.Println("    \"order\": [\"local\", \"youtube\"],        Default for -source")
+	fmt.Println("    \"local_dir\": \"~/Music\",                Files named \"<artist> - <title>\"")
+	fmt.Println("    \"command\": \"fetch {isrc} {output}\",    Must write the file {output}")
+	fmt.Println("    \"duration_tolerance_seconds\": 15       Reject search results this far off")
+	fmt.Println("  }")
+	fmt.Println("  Command placeholders: {artist} {title} {isrc} {id} {format} {output}")
+	fmt.Println("")
+	fmt.Println("SONG.LINK API KEY:")
+	fmt.Println("  \"songlink_api_key\": \"...\",          Sent as key= on every request")
+	fmt.Println("  \"songlink_requests_per_minute\": 60  Client-side rate limit (default: 10)")
+	fmt.Println("")
 	fmt.Println("SECURITY:")
 	fmt.Println("  Credentials are stored locally and never transmitted")
 	fmt.Println("  except to Apple's API servers.")
@@ -622,10 +1359,10 @@ func loadingIndicator(stop chan bool) {
 	for {
 		select {
 		case <-stop:
-			fmt.Print("\r")
+			fmt.Fprint(ui(), "\r")
 			return
 		default:
-			fmt.Printf("\rLoading %s", chars[i])
+			fmt.Fprintf(ui(), "\rLoading %s", chars[i])
 			i = (i + 1) % len(chars)
 			time.Sleep(100 * time.Millisecond)
 		}
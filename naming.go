@@ -0,0 +1,221 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// defaultNameTemplate is how tracks were always named: flat in the output
+// directory.
+const defaultNameTemplate = "{artist} - {title}"
+
+var builtinNameTemplates = map[string]string{
+	"flat":    defaultNameTemplate,
+	"library": "{albumartist}/{year} - {album}/{disc}-{track:02} {title}",
+}
+
+var nameTemplateFlag = flag.String("name-template", "", "Name downloaded files with a named or inline template (default: config name_template, or \""+defaultNameTemplate+"\")")
+
+// nameFields are the placeholders a name template can use.
+var nameFields = map[string]bool{
+	"artist": true, "title": true, "album": true, "albumartist": true,
+	"year": true, "date": true, "track": true, "tracks": true, "disc": true,
+	"genre": true, "composer": true, "isrc": true, "id": true,
+}
+
+// nameTemplate returns the template from -name-template or the config's
+// name_template. Names are looked up in the config's name_templates and then
+// in the built-in templates; anything containing "{" is used as is.
+func nameTemplate() (string, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return "", fmt.Errorf("error loading config: %w", err)
+	}
+	name := *nameTemplateFlag
+	if name == "" {
+		name = config.NameTemplate
+	}
+	if name == "" {
+		return defaultNameTemplate, nil
+	}
+
+	text := name
+	if !strings.Contains(name, "{") {
+		var ok bool
+		if text, ok = config.NameTemplates[name]; !ok {
+			if text, ok = builtinNameTemplates[name]; !ok {
+				return "", fmt.Errorf("unknown name template %q (available: %s)", name, strings.Join(nameTemplateNames(config), ", "))
+			}
+		}
+	}
+	if _, err := renderName(text, SearchResult{}); err != nil {
+		return "", err
+	}
+	return text, nil
+}
+
+func nameTemplateNames(config *Config) []string {
+	var names []string
+	for name := range builtinNameTemplates {
+		if _, ok := config.NameTemplates[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	for name := range config.NameTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// renderName fills in text's placeholders, e.g. "{album}" or "{track:02}"
+// for a track number padded to two digits, and turns the result into a
+// relative path: "/" separates directories and every part is passed through
+// sanitizeFileName. Spaces and hyphens next to a placeholder left empty by a
+// missing field are removed, and so are directories that end up empty.
+func renderName(text string, track SearchResult) (string, error) {
+	// pieces alternates between literal text and placeholder values, so
+	// placeholders are at the odd indices.
+	var pieces []string
+	rest := text
+	for {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unclosed placeholder in name template %q", text)
+		}
+		value, err := nameField(rest[open+1:open+end], track)
+		if err != nil {
+			return "", fmt.Errorf("name template %q: %w", text, err)
+		}
+		pieces = append(pieces, rest[:open], strings.NewReplacer("/", "_", "\\", "_").Replace(value))
+		rest = rest[open+end+1:]
+	}
+	pieces = append(pieces, rest)
+
+	for i := 1; i < len(pieces); i += 2 {
+		if pieces[i] == "" {
+			pieces[i-1] = strings.TrimRight(pieces[i-1], " -")
+			pieces[i+1] = strings.TrimLeft(pieces[i+1], " -")
+		}
+	}
+
+	var parts []string
+	for _, part := range strings.Split(strings.Join(pieces, ""), "/") {
+		if strings.TrimSpace(part) != "" {
+			parts = append(parts, sanitizeFileName(part))
+		}
+	}
+	if len(parts) == 0 {
+		return sanitizeFileName(fmt.Sprintf("%s - %s", track.ArtistName, track.Name)), nil
+	}
+	return filepath.Join(parts...), nil
+}
+
+// nameField returns the value of a placeholder such as "title" or
+// "track:02".
+func nameField(placeholder string, track SearchResult) (string, error) {
+	field, format, _ := strings.Cut(placeholder, ":")
+	if !nameFields[field] {
+		return "", fmt.Errorf("unknown placeholder {%s}", placeholder)
+	}
+	width := 0
+	if format != "" {
+		var err error
+		if width, err = strconv.Atoi(format); err != nil || width < 0 {
+			return "", fmt.Errorf("invalid width in {%s}", placeholder)
+		}
+	}
+
+	number := func(n int) string {
+		if n <= 0 {
+			return ""
+		}
+		return fmt.Sprintf("%0*d", width, n)
+	}
+	switch field {
+	case "artist":
+		return track.ArtistName, nil
+	case "title":
+		return track.Name, nil
+	case "album":
+		return track.AlbumName, nil
+	case "albumartist":
+		if track.AlbumArtist != "" {
+			return track.AlbumArtist, nil
+		}
+		return track.ArtistName, nil
+	case "year":
+		if len(track.ReleaseDate) >= 4 {
+			return track.ReleaseDate[:4], nil
+		}
+		return "", nil
+	case "date":
+		return track.ReleaseDate, nil
+	case "track":
+		return number(track.TrackNumber), nil
+	case "tracks":
+		return number(track.TrackCount), nil
+	case "disc":
+		return number(track.DiscNumber), nil
+	case "genre":
+		return track.Genre, nil
+	case "composer":
+		return track.Composer, nil
+	case "isrc":
+		return track.ISRC, nil
+	default:
+		return track.ID, nil
+	}
+}
+
+// maxFileNameBytes leaves room below the usual 255 byte limit for the
+// extension, " (2)" suffixes and the temporary files of yt-dlp and ffmpeg.
+const maxFileNameBytes = 200
+
+var (
+	invalidFileNameChars = regexp.MustCompile(`[\\/:*?"<>|\x00-\x1f\x7f]`)
+	// reservedFileNames can't be used on Windows, not even with an
+	// extension.
+	reservedFileNames = regexp.MustCompile(`(?i)^(con|prn|aux|nul|com[0-9]|lpt[0-9])(\..*)?$`)
+)
+
+// sanitizeFileName makes name usable as a single file or directory name on
+// Linux, macOS and Windows. The name is normalized to Unicode NFC so the
+// same title always maps to the same file.
+func sanitizeFileName(name string) string {
+	name = norm.NFC.String(name)
+	name = invalidFileNameChars.ReplaceAllString(name, "_")
+	name = truncateBytes(name, maxFileNameBytes)
+	// Windows drops trailing dots and spaces.
+	name = strings.TrimRight(strings.TrimLeft(name, " "), ". ")
+	if reservedFileNames.MatchString(name) {
+		name = "_" + name
+	}
+	if name == "" {
+		return "_"
+	}
+	return name
+}
+
+// truncateBytes cuts s to at most n bytes without splitting a character.
+func truncateBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
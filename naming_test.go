@@ -0,0 +1,78 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderName(t *testing.T) {
+	track := SearchResult{
+		ID:          "1",
+		Name:        "Back in Black",
+		ArtistName:  "AC/DC",
+		AlbumName:   "Back in Black",
+		AlbumArtist: "AC/DC",
+		TrackNumber: 6,
+		DiscNumber:  1,
+		ReleaseDate: "1980-07-25",
+	}
+	tests := []struct {
+		template string
+		track    SearchResult
+		want     string
+	}{
+		{defaultNameTemplate, track, "AC_DC - Back in Black"},
+		{builtinNameTemplates["library"], track, filepath.Join("AC_DC", "1980 - Back in Black", "1-06 Back in Black")},
+		// Missing fields leave no stray separators or empty directories.
+		{"{albumartist}/{year} - {album}/{genre}/{track:02} {title}", SearchResult{Name: "Song", ArtistName: "Artist"}, filepath.Join("Artist", "Song")},
+		{"{genre}", SearchResult{Name: "Song", ArtistName: "Artist"}, "Artist - Song"},
+		{"{disc}-{track:02} {title}", SearchResult{Name: "Song", TrackNumber: 3}, "03 Song"},
+		// Hyphens that are part of the names or the template are kept.
+		{defaultNameTemplate, SearchResult{Name: "Song -", ArtistName: "-M-"}, "-M- - Song -"},
+		{"{title} -live-", track, "Back in Black -live-"},
+	}
+	for _, tt := range tests {
+		got, err := renderName(tt.template, tt.track)
+		if err != nil {
+			t.Errorf("renderName(%q) returned an unexpected error: %v", tt.template, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("renderName(%q) = %q; want %q", tt.template, got, tt.want)
+		}
+	}
+
+	for _, template := range []string{"{unknown}", "{title", "{track:x}"} {
+		if _, err := renderName(template, track); err == nil {
+			t.Errorf("renderName(%q) returned no error", template)
+		}
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{`AC/DC: "Live"?`, "AC_DC_ _Live__"},
+		{"Vol. 2...", "Vol. 2"},
+		{"  Intro ", "Intro"},
+		{"CON", "_CON"},
+		{"lpt1.mp3", "_lpt1.mp3"},
+		{"Console", "Console"},
+		{"Cafe\u0301", "Caf\u00e9"},
+		{"tab\there", "tab_here"},
+		{"...", "_"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeFileName(tt.name); got != tt.want {
+			t.Errorf("sanitizeFileName(%q) = %q; want %q", tt.name, got, tt.want)
+		}
+	}
+
+	long := sanitizeFileName(strings.Repeat("é", 150))
+	if len(long) > maxFileNameBytes || !strings.HasPrefix(strings.Repeat("é", 150), long) {
+		t.Errorf("sanitizeFileName of a long name = %q (%d bytes); want at most %d bytes on a character boundary", long, len(long), maxFileNameBytes)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+
+	"golang.org/x/term"
+)
+
+// ui returns the writer for human-readable output. In -json mode stdout is
+// reserved for the JSON document, so prompts and progress go to stderr.
+func ui() io.Writer {
+	if *jsonFlag {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// isTerminal reports whether f is attached to a terminal rather than a pipe
+// or file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalWidth returns the number of columns of terminal f, or 80 when it
+// can't be determined. $COLUMNS overrides the size reported by the terminal.
+func terminalWidth(f *os.File) int {
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 20 {
+		return cols
+	}
+	if cols, _, err := term.GetSize(int(f.Fd())); err == nil && cols > 20 {
+		return cols
+	}
+	return 80
+}
+
+// hasPipedInput reports whether f is a pipe or a non-empty file, i.e. input
+// was redirected on purpose. /dev/null, sockets and terminals don't count,
+// so cron jobs and ssh sessions don't wait on an input that never comes.
+func hasPipedInput(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	mode := info.Mode()
+	return mode&os.ModeNamedPipe != 0 || (mode.IsRegular() && info.Size() > 0)
+}
+
+func printJSON(v interface{}) error {
+	return writeJSON(os.Stdout, v)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(v)
+}
+
+type ErrorOutput struct {
+	Error string `json:"error"`
+}
+
+type LinksOutput struct {
+	URL string `json:"url"`
+	ShareData
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type SearchOutput struct {
+	Query    string        `json:"query"`
+	Selected *SearchResult `json:"selected"`
+	Links    *LinksOutput  `json:"links,omitempty"`
+	Download *FileOutput   `json:"download,omitempty"`
+}
+
+type FileOutput struct {
+	FilePath        string          `json:"file_path"`
+	Format          string          `json:"format"`
+	Outcome         DownloadOutcome `json:"outcome"`
+	DurationSeconds float64         `json:"duration_seconds"`
+}
+
+type DownloadOutput struct {
+	Query    string        `json:"query"`
+	Selected *SearchResult `json:"selected"`
+	FileOutput
+}
+
+type PlaylistOutput struct {
+	Type            string           `json:"type"`
+	ID              string           `json:"id"`
+	Name            string           `json:"name"`
+	Artist          string           `json:"artist,omitempty"`
+	Curator         string           `json:"curator,omitempty"`
+	SourceURL       string           `json:"source_url"`
+	Total           int32            `json:"total"`
+	Completed       int32            `json:"completed"`
+	Skipped         int32            `json:"skipped"`
+	Failed          int32            `json:"failed"`
+	Cancelled       int32            `json:"cancelled"`
+	DurationSeconds float64          `json:"duration_seconds"`
+	Results         []DownloadResult `json:"results"`
+}
+
+func NewPlaylistOutput(metadata *PlaylistMetadata, sourceURL string, results []DownloadResult, progress *ProgressTracker) PlaylistOutput {
+	total, completed, failed := progress.GetStats()
+	return PlaylistOutput{
+		Type:            metadata.Type,
+		ID:              metadata.ID,
+		Name:            metadata.Name,
+		Artist:          metadata.Artist,
+		Curator:         metadata.Curator,
+		SourceURL:       sourceURL,
+		Total:           total,
+		Completed:       completed,
+		Skipped:         progress.Skipped(),
+		Failed:          failed,
+		Cancelled:       progress.Cancelled(),
+		DurationSeconds: progress.Elapsed().Seconds(),
+		Results:         results,
+	}
+}
+
+type ArtistOutput struct {
+	ID              string           `json:"id"`
+	Name            string           `json:"name"`
+	SourceURL       string           `json:"source_url"`
+	Total           int32            `json:"total"`
+	Completed       int32            `json:"completed"`
+	Failed          int32            `json:"failed"`
+	Cancelled       int32            `json:"cancelled"`
+	DurationSeconds float64          `json:"duration_seconds"`
+	Releases        []ArtistRelease  `json:"releases"`
+	Albums          []PlaylistOutput `json:"albums,omitempty"`
+}
@@ -10,57 +10,180 @@ import (
 )
 
 type PlaylistURLParser struct {
-	playlistPattern *regexp.Regexp
-	albumPattern    *regexp.Regexp
+	storefrontPattern *regexp.Regexp
+	catalogIDPattern  *regexp.Regexp
+	playlistIDPattern *regexp.Regexp
+	// defaultStorefront returns the storefront for URLs without one.
+	defaultStorefront func() string
 }
 
 func NewPlaylistURLParser() *PlaylistURLParser {
 	return &PlaylistURLParser{
-		playlistPattern: regexp.MustCompile(`music\.apple\.com/([a-z]{2})/playlist/[^/]+/pl\.([a-zA-Z0-9]+)`),
-		albumPattern: regexp.MustCompile(`music\.apple\.com/([a-z]{2})/album/[^/]+/(\d+)`),
+		storefrontPattern: regexp.MustCompile(`^[a-z]{2}$`),
+		catalogIDPattern:  regexp.MustCompile(`^(?:id)?(\d+)$`),
+		playlistIDPattern: regexp.MustCompile(`^pl\.[a-zA-Z0-9-]+$`),
+		defaultStorefront: defaultStorefront,
 	}
 }
 
 type ParseResourceType string
 
 const (
-	ParsedPlaylist ParseResourceType = "playlist"
-	ParsedAlbum    ParseResourceType = "album"
+	ParsedPlaylist   ParseResourceType = "playlist"
+	ParsedAlbum      ParseResourceType = "album"
+	ParsedSong       ParseResourceType = "song"
+	ParsedArtist     ParseResourceType = "artist"
+	ParsedMusicVideo ParseResourceType = "music-video"
 )
 
+var ErrNotAppleMusicURL = errors.New("not an Apple Music URL")
+
+var appleMusicHosts = map[string]bool{
+	"music.apple.com":       true,
+	"geo.music.apple.com":   true,
+	"embed.music.apple.com": true,
+	"itunes.apple.com":      true,
+}
+
 type ParsedResource struct {
 	Type       ParseResourceType
 	ID         string
 	Storefront string
 }
 
+// Parse recognizes Apple Music and iTunes URLs of the form
+// [/<storefront>]/<kind>[/<slug>]/<id>, where kind is album, song, playlist,
+// artist or music-video. Album URLs carrying an ?i=<id> query point at a
+// single track and are parsed as songs.
 func (p *PlaylistURLParser) Parse(inputURL string) (*ParsedResource, error) {
-	parsedURL, err := url.Parse(inputURL)
+	parsedURL, err := url.Parse(strings.TrimSpace(inputURL))
 	if err != nil {
 		return nil, fmt.Errorf("invalid URL: %w", err)
 	}
 
-	if !strings.Contains(parsedURL.Host, "music.apple.com") {
-		return nil, errors.New("not an Apple Music URL")
+	if !appleMusicHosts[strings.ToLower(parsedURL.Hostname())] {
+		return nil, ErrNotAppleMusicURL
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(parsedURL.Path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	var storefront string
+	if len(segments) > 0 && p.storefrontPattern.MatchString(strings.ToLower(segments[0])) {
+		storefront = strings.ToLower(segments[0])
+		segments = segments[1:]
+	}
+
+	if len(segments) > 0 && segments[0] == "library" {
+		return nil, errors.New("library playlists are private and can't be fetched from the Apple Music catalog")
+	}
+	if len(segments) < 2 {
+		return nil, errors.New("unrecognized Apple Music URL format")
+	}
+
+	kind := ParseResourceType(segments[0])
+	id := segments[len(segments)-1]
+
+	switch kind {
+	case ParsedPlaylist:
+		if !p.playlistIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid playlist ID: %s", id)
+		}
+	case ParsedAlbum, ParsedSong, ParsedArtist, ParsedMusicVideo:
+		matches := p.catalogIDPattern.FindStringSubmatch(id)
+		if matches == nil {
+			return nil, fmt.Errorf("invalid %s ID: %s", kind, id)
+		}
+		id = matches[1]
+
+		if trackID := parsedURL.Query().Get("i"); kind == ParsedAlbum && trackID != "" {
+			if matches := p.catalogIDPattern.FindStringSubmatch(trackID); matches != nil {
+				kind, id = ParsedSong, matches[1]
+			}
+		}
+	default:
+		return nil, errors.New("unrecognized Apple Music URL format")
+	}
+
+	if storefront == "" {
+		storefront = p.defaultStorefront()
+	}
+
+	return &ParsedResource{
+		Type:       kind,
+		ID:         id,
+		Storefront: storefront,
+	}, nil
+}
+
+// ResolveResource parses Apple Music URLs directly and resolves URLs from
+// other services (Spotify, YouTube Music, Tidal, Deezer, ...) to the matching
+// Apple Music album or song through song.link.
+func (p *PlaylistURLParser) ResolveResource(ctx context.Context, inputURL string) (*ParsedResource, error) {
+	resource, err := p.Parse(inputURL)
+	if !errors.Is(err, ErrNotAppleMusicURL) {
+		return resource, err
+	}
+
+	linksResponse, err := FetchLinks(ctx, inputURL)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving URL through song.link: %w", err)
+	}
+
+	return appleMusicResource(linksResponse)
+}
+
+// appleMusicResource finds the Apple Music entity in a song.link response.
+func appleMusicResource(linksResponse *SonglinkResponse) (*ParsedResource, error) {
+	link, ok := linksResponse.LinksByPlatform["appleMusic"]
+	if !ok {
+		link, ok = linksResponse.LinksByPlatform["itunes"]
+	}
+	if !ok || link.EntityUniqueID == "" {
+		return nil, errors.New("no Apple Music equivalent found for this URL")
 	}
 
-	if matches := p.playlistPattern.FindStringSubmatch(inputURL); len(matches) > 2 {
-		return &ParsedResource{
-			Type:       ParsedPlaylist,
-			ID:         matches[2],
-			Storefront: matches[1],
-		}, nil
+	entity, ok := linksResponse.EntitiesByUniqueID[link.EntityUniqueID]
+	if !ok {
+		return nil, fmt.Errorf("song.link response is missing entity %s", link.EntityUniqueID)
 	}
 
-	if matches := p.albumPattern.FindStringSubmatch(inputURL); len(matches) > 2 {
-		return &ParsedResource{
-			Type:       ParsedAlbum,
-			ID:         matches[2],
-			Storefront: matches[1],
-		}, nil
+	resource := &ParsedResource{
+		ID:         entity.ID,
+		Storefront: appleMusicStorefront(link.URL),
+	}
+	switch entity.Type {
+	case "album":
+		resource.Type = ParsedAlbum
+	case "song":
+		resource.Type = ParsedSong
+	default:
+		return nil, fmt.Errorf("unsupported Apple Music resource type: %s", entity.Type)
 	}
 
-	return nil, errors.New("unrecognized Apple Music URL format")
+	return resource, nil
+}
+
+// appleMusicStorefront returns the storefront of an Apple Music URL, falling
+// back to defaultStorefront.
+func appleMusicStorefront(musicURL string) string {
+	if resource, err := NewPlaylistURLParser().Parse(musicURL); err == nil {
+		return resource.Storefront
+	}
+	return defaultStorefront()
+}
+
+// defaultStorefront is used for URLs without a storefront segment: the
+// -country lookup option if set, otherwise "us".
+func defaultStorefront() string {
+	if client, err := defaultSonglinkClient(); err == nil && client.Options.UserCountry != "" {
+		return strings.ToLower(client.Options.UserCountry)
+	}
+	return "us"
 }
 
 type ExtendedMusicSearcher struct {
@@ -92,34 +215,59 @@ func (ems *ExtendedMusicSearcher) GetAlbumWithTracks(ctx context.Context, albumI
 
 	var tracks []SearchResult
 	for _, song := range songs {
-		artURL := song.Attributes.Artwork.URL
-		artURL = strings.ReplaceAll(artURL, "{w}", "500")
-		artURL = strings.ReplaceAll(artURL, "{h}", "500")
-		
-		tracks = append(tracks, SearchResult{
-			ID:         song.ID,
-			Name:       song.Attributes.Name,
-			ArtistName: song.Attributes.ArtistName,
-			Type:       Song,
-			URL:        song.Attributes.URL,
-			ArtworkURL: artURL,
-		})
-	}
-
-	artURL := album.Attributes.Artwork.URL
-	artURL = strings.ReplaceAll(artURL, "{w}", "500")
-	artURL = strings.ReplaceAll(artURL, "{h}", "500")
+		track := NewSongResult(song)
+		track.AlbumArtist = album.Attributes.ArtistName
+		track.TrackCount = album.Attributes.TrackCount
+		tracks = append(tracks, track)
+	}
 
 	return &AlbumWithTracks{
 		ID:         album.ID,
 		Name:       album.Attributes.Name,
 		ArtistName: album.Attributes.ArtistName,
-		ArtworkURL: artURL,
+		ArtworkURL: artworkURL(album.Attributes.Artwork.URL, 500),
 		Tracks:     tracks,
 		TrackCount: album.Attributes.TrackCount,
 	}, nil
 }
 
+func (ems *ExtendedMusicSearcher) GetSong(ctx context.Context, songID string, storefront string) (*SearchResult, error) {
+	apiClient := NewAppleMusicClient(ems.client.DeveloperToken)
+
+	song, err := apiClient.GetSong(ctx, storefront, songID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get song: %w", err)
+	}
+
+	result := NewSongResult(*song)
+	return &result, nil
+}
+
+func (ems *ExtendedMusicSearcher) GetMusicVideo(ctx context.Context, videoID string, storefront string) (*SearchResult, error) {
+	apiClient := NewAppleMusicClient(ems.client.DeveloperToken)
+
+	video, err := apiClient.GetMusicVideo(ctx, storefront, videoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get music video: %w", err)
+	}
+
+	return &SearchResult{
+		ID:             video.ID,
+		Name:           video.Attributes.Name,
+		ArtistName:     video.Attributes.ArtistName,
+		Type:           MusicVideo,
+		URL:            video.Attributes.URL,
+		ArtworkURL:     artworkURL(video.Attributes.Artwork.URL, 500),
+		ISRC:           video.Attributes.ISRC,
+		DurationMillis: video.Attributes.DurationInMillis,
+		TrackNumber:    video.Attributes.TrackNumber,
+		ReleaseDate:    video.Attributes.ReleaseDate,
+		Genre:          primaryGenre(video.Attributes.GenreNames),
+		ContentRating:  video.Attributes.ContentRating,
+		FullArtworkURL: fullArtworkURL(video.Attributes.Artwork),
+	}, nil
+}
+
 func (ems *ExtendedMusicSearcher) GetPlaylistWithTracks(ctx context.Context, playlistID string, storefront string) (*PlaylistWithTracks, error) {
 	apiClient := NewAppleMusicClient(ems.client.DeveloperToken)
 
@@ -135,32 +283,14 @@ func (ems *ExtendedMusicSearcher) GetPlaylistWithTracks(ctx context.Context, pla
 
 	var tracks []SearchResult
 	for _, song := range songs {
-		artURL := song.Attributes.Artwork.URL
-		artURL = strings.ReplaceAll(artURL, "{w}", "500")
-		artURL = strings.ReplaceAll(artURL, "{h}", "500")
-		
-		tracks = append(tracks, SearchResult{
-			ID:         song.ID,
-			Name:       song.Attributes.Name,
-			ArtistName: song.Attributes.ArtistName,
-			Type:       Song,
-			URL:        song.Attributes.URL,
-			ArtworkURL: artURL,
-		})
-	}
-
-	artURL := ""
-	if playlist.Attributes.Artwork.URL != "" {
-		artURL = playlist.Attributes.Artwork.URL
-		artURL = strings.ReplaceAll(artURL, "{w}", "500")
-		artURL = strings.ReplaceAll(artURL, "{h}", "500")
+		tracks = append(tracks, NewSongResult(song))
 	}
 
 	return &PlaylistWithTracks{
 		ID:          playlist.ID,
 		Name:        playlist.Attributes.Name,
 		CuratorName: playlist.Attributes.CuratorName,
-		ArtworkURL:  artURL,
+		ArtworkURL:  artworkURL(playlist.Attributes.Artwork.URL, 500),
 		Tracks:      tracks,
 		TrackCount:  playlist.Attributes.TrackCount,
 	}, nil
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type PlaylistLinksOutput struct {
+	Type      string          `json:"type"`
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Artist    string          `json:"artist,omitempty"`
+	Curator   string          `json:"curator,omitempty"`
+	SourceURL string          `json:"source_url"`
+	Tracks    []ConvertRecord `json:"tracks"`
+}
+
+// ResolveTrackLinks resolves the Apple Music URL of every track through
+// song.link. Title and artist come from Apple Music so that tracks song.link
+// can't resolve are still identifiable.
+func ResolveTrackLinks(ctx context.Context, tracks []SearchResult, concurrency int) []ConvertRecord {
+	urls := make([]string, len(tracks))
+	for i, track := range tracks {
+		urls[i] = track.URL
+	}
+
+	resolve := func(ctx context.Context, searchURL string) (*LinksOutput, error) {
+		if searchURL == "" {
+			return nil, fmt.Errorf("track has no Apple Music URL")
+		}
+		return resolveAllPlatforms(ctx, searchURL)
+	}
+
+	records := NewConvertRecords(resolveAll(ctx, urls, concurrency, resolve))
+	for i, track := range tracks {
+		records[i].Title = track.Name
+		records[i].Artist = track.ArtistName
+	}
+	return records
+}
+
+// filterPlatforms drops links for platforms that weren't requested.
+func filterPlatforms(records []ConvertRecord, platforms []string) {
+	if len(platforms) == 0 {
+		return
+	}
+	for i := range records {
+		if records[i].Links == nil {
+			continue
+		}
+		filtered := make(map[string]string)
+		for _, p := range platforms {
+			if link, ok := records[i].Links[p]; ok {
+				filtered[p] = link
+			}
+		}
+		records[i].Links = filtered
+	}
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"(", `\(`, ")", `\)`, "<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`, "~", `\~`,
+)
+
+// escapeMarkdown makes text from Apple Music or song.link safe to put in
+// Markdown, so names such as "*NSYNC" or "[Live]" are shown as written.
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+// markdownLinkEscaper percent-encodes the characters that would end a
+// Markdown link target early.
+var markdownLinkEscaper = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29")
+
+// markdownLink formats a Markdown link to target.
+func markdownLink(text, target string) string {
+	return fmt.Sprintf("[%s](%s)", text, markdownLinkEscaper.Replace(target))
+}
+
+// WritePlaylistMarkdown writes a numbered list of tracks with a song.link
+// link and one link per platform.
+func WritePlaylistMarkdown(w io.Writer, output *PlaylistLinksOutput) error {
+	heading := output.Name
+	if output.Artist != "" {
+		heading += " - " + output.Artist
+	} else if output.Curator != "" {
+		heading += " by " + output.Curator
+	}
+	if _, err := fmt.Fprintf(w, "# %s\n\n", escapeMarkdown(heading)); err != nil {
+		return err
+	}
+
+	for i, record := range output.Tracks {
+		line := fmt.Sprintf("%d. **%s - %s**", i+1, escapeMarkdown(record.Artist), escapeMarkdown(record.Title))
+		if record.Error != "" {
+			line += " (no links: " + escapeMarkdown(record.Error) + ")"
+		} else {
+			var links []string
+			if record.PageURL != "" {
+				links = append(links, markdownLink("song.link", record.PageURL))
+			}
+			linksByPlatform := make(LinksByPlatform)
+			for platform := range record.Links {
+				linksByPlatform[platform] = PlatformMusic{}
+			}
+			for _, platform := range linksByPlatform.Platforms() {
+				links = append(links, markdownLink(platformName(platform), record.Links[platform]))
+			}
+			if len(links) > 0 {
+				line += " - " + strings.Join(links, " · ")
+			}
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// linksFormat picks the output format: -format when given, else json for
+// -json on stdout, else the -out extension when it is a known format, else
+// markdown.
+func linksFormat(format, out string, jsonOutput bool) (string, error) {
+	if format != "" {
+		switch strings.ToLower(format) {
+		case "md", "markdown":
+			return "markdown", nil
+		case "csv", "json":
+			return strings.ToLower(format), nil
+		default:
+			return "", fmt.Errorf("unsupported output format: %s", format)
+		}
+	}
+	if jsonOutput && out == "-" {
+		return "json", nil
+	}
+	switch ext := strings.ToLower(filepath.Ext(out)); ext {
+	case ".csv", ".json":
+		return ext[1:], nil
+	default:
+		return "markdown", nil
+	}
+}
+
+func executePlaylistLinks(args []string) error {
+	linksCmd := flag.NewFlagSet("playlist links", flag.ExitOnError)
+	formatFlag := linksCmd.String("format", "", "Output format: markdown, csv or json (default: from -out extension, else markdown)")
+	outFlag := linksCmd.String("out", "-", "Output file (default: stdout)")
+	concurrentFlag := linksCmd.Int("concurrent", 4, "Number of tracks to resolve in parallel")
+	linksCmd.Var(&platformFlag, "platform", "Only include links for a platform (repeatable)")
+	linksCmd.BoolVar(jsonFlag, "json", *jsonFlag, "Print the links as JSON")
+	registerLookupFlags(linksCmd)
+	registerCacheFlag(linksCmd)
+	helpFlag := linksCmd.Bool("help", false, "Show help for playlist links")
+	hFlag := linksCmd.Bool("h", false, "Show help for playlist links")
+
+	if err := linksCmd.Parse(reorderArgs(args, map[string]bool{"format": true, "out": true, "concurrent": true, "platform": true, "country": true})); err != nil {
+		return err
+	}
+
+	if *helpFlag || *hFlag {
+		printPlaylistHelp()
+		os.Exit(0)
+	}
+
+	if linksCmd.NArg() == 0 {
+		return fmt.Errorf("music URL required")
+	}
+	musicURL := linksCmd.Arg(0)
+
+	format, err := linksFormat(*formatFlag, *outFlag, *jsonFlag)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	resource, err := resolveMusicURL(ctx, musicURL)
+	if err != nil {
+		return err
+	}
+	fetchCtx, cancel := context.WithTimeout(ctx, catalogFetchTimeout)
+	tracks, metadata, err := fetchCollectionTracks(fetchCtx, musicURL, resource)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Resolving links for %d tracks...\n", len(tracks))
+	records := ResolveTrackLinks(ctx, tracks, *concurrentFlag)
+	if wasInterrupted(ctx) {
+		return errInterrupted
+	}
+	filterPlatforms(records, platformFlag)
+
+	output := &PlaylistLinksOutput{
+		Type:      metadata.Type,
+		ID:        metadata.ID,
+		Name:      metadata.Name,
+		Artist:    metadata.Artist,
+		Curator:   metadata.Curator,
+		SourceURL: musicURL,
+		Tracks:    records,
+	}
+
+	var w io.Writer = os.Stdout
+	if *outFlag != "-" {
+		f, err := os.Create(*outFlag)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch format {
+	case "json":
+		err = writeJSON(w, output)
+	case "csv":
+		err = WriteConvertCSV(w, records)
+	default:
+		err = WritePlaylistMarkdown(w, output)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write links: %w", err)
+	}
+
+	if *outFlag != "-" {
+		fmt.Fprintf(os.Stderr, "Wrote links for %d tracks to %s\n", len(records), *outFlag)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWritePlaylistMarkdownEscapes(t *testing.T) {
+	output := &PlaylistLinksOutput{
+		Name:   "[Best] of_2024",
+		Artist: "*NSYNC",
+		Tracks: []ConvertRecord{
+			{Artist: "*NSYNC", Title: "Bye Bye Bye [Live]", PageURL: "https://song.link/i/1"},
+			{Artist: "Artist", Title: "Song", Error: "no match for *this*"},
+			{Artist: "Artist", Title: "Wiki", PageURL: "https://song.link/i/2", Links: map[string]string{
+				"spotify": "https://example.com/Song (Live) 1",
+			}},
+			{Artist: "Artist", Title: "No Page", Links: map[string]string{"tidal": "https://tidal.com/track/3"}},
+		},
+	}
+
+	var sb strings.Builder
+	if err := WritePlaylistMarkdown(&sb, output); err != nil {
+		t.Fatalf("WritePlaylistMarkdown returned an unexpected error: %v", err)
+	}
+	want := "# \\[Best\\] of\\_2024 - \\*NSYNC\n\n" +
+		"1. **\\*NSYNC - Bye Bye Bye \\[Live\\]** - [song.link](https://song.link/i/1)\n" +
+		"2. **Artist - Song** (no links: no match for \\*this\\*)\n" +
+		"3. **Artist - Wiki** - [song.link](https://song.link/i/2) · [Spotify](https://example.com/Song%20%28Live%29%201)\n" +
+		"4. **Artist - No Page** - [Tidal](https://tidal.com/track/3)\n"
+	if got := sb.String(); got != want {
+		t.Errorf("WritePlaylistMarkdown wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLinksFormat(t *testing.T) {
+	tests := []struct {
+		format, out string
+		json        bool
+		want        string
+	}{
+		{"", "-", false, "markdown"},
+		{"", "-", true, "json"},
+		{"", "links.csv", false, "csv"},
+		{"", "links.JSON", false, "json"},
+		{"", "links.txt", false, "markdown"},
+		{"md", "links.csv", false, "markdown"},
+	}
+	for _, tt := range tests {
+		got, err := linksFormat(tt.format, tt.out, tt.json)
+		if err != nil || got != tt.want {
+			t.Errorf("linksFormat(%q, %q, %v) = %q, %v; want %q", tt.format, tt.out, tt.json, got, err, tt.want)
+		}
+	}
+
+	if _, err := linksFormat("txt", "-", false); err == nil {
+		t.Error("linksFormat with -format=txt returned no error")
+	}
+}
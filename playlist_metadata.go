@@ -28,6 +28,13 @@ type TrackMetadata struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
 	Artist      string `json:"artist"`
+	Album       string `json:"album,omitempty"`
+	TrackNumber int    `json:"track_number,omitempty"`
+	DiscNumber  int    `json:"disc_number,omitempty"`
+	ISRC        string `json:"isrc,omitempty"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	Genre       string `json:"genre,omitempty"`
+	Composer    string `json:"composer,omitempty"`
 	Duration    int    `json:"duration_seconds,omitempty"`
 	FilePath    string `json:"file_path"`
 	Downloaded  bool   `json:"downloaded"`
@@ -35,16 +42,23 @@ type TrackMetadata struct {
 	Error       string `json:"error,omitempty"`
 }
 
+func MetadataFilePath(metadata *PlaylistMetadata, outputDir string) string {
+	return filepath.Join(outputDir, sanitizeFileName(metadata.Name)+"_metadata.json")
+}
+
 func SavePlaylistMetadata(metadata *PlaylistMetadata, outputDir string) error {
-	filename := sanitizeFileName(metadata.Name) + "_metadata.json"
-	filepath := filepath.Join(outputDir, filename)
-	
+	return WritePlaylistMetadata(metadata, MetadataFilePath(metadata, outputDir))
+}
+
+// WritePlaylistMetadata saves metadata to path, e.g. the file a download was
+// resumed from.
+func WritePlaylistMetadata(metadata *PlaylistMetadata, path string) error {
 	data, err := json.MarshalIndent(metadata, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal metadata: %w", err)
 	}
 	
-	if err := os.WriteFile(filepath, data, 0644); err != nil {
+	if err := os.WriteFile(path, data, 0644); err != nil {
 		return fmt.Errorf("failed to write metadata file: %w", err)
 	}
 	
@@ -65,14 +79,21 @@ func LoadPlaylistMetadata(filepath string) (*PlaylistMetadata, error) {
 	return &metadata, nil
 }
 
-func (pm *PlaylistMetadata) UpdateTrackStatus(trackID string, downloaded bool, filepath string, err error) {
+// UpdateTrackStatus records the outcome of a track's download. The file path
+// is stored as an absolute path so the metadata can be resumed from any
+// directory.
+func (pm *PlaylistMetadata) UpdateTrackStatus(trackID string, downloaded bool, path string, err error) {
+	if abs, absErr := filepath.Abs(path); path != "" && absErr == nil {
+		path = abs
+	}
 	for i, track := range pm.Tracks {
 		if track.ID == trackID {
 			pm.Tracks[i].Downloaded = downloaded
 			if downloaded {
 				now := time.Now()
 				pm.Tracks[i].DownloadedAt = &now
-				pm.Tracks[i].FilePath = filepath
+				pm.Tracks[i].FilePath = path
+				pm.Tracks[i].Error = ""
 			}
 			if err != nil {
 				pm.Tracks[i].Error = err.Error()
@@ -82,6 +103,62 @@ func (pm *PlaylistMetadata) UpdateTrackStatus(trackID string, downloaded bool, f
 	}
 }
 
+// ResumeFrom copies the download status of tracks that a previous run saved
+// as downloaded and whose file still exists. Failed and missing tracks keep
+// their fresh state so they are downloaded again. It returns the number of
+// tracks carried over.
+func (pm *PlaylistMetadata) ResumeFrom(saved *PlaylistMetadata) int {
+	previous := make(map[string]TrackMetadata)
+	for _, track := range saved.Tracks {
+		previous[track.ID] = track
+	}
+
+	resumed := 0
+	for i, track := range pm.Tracks {
+		old, ok := previous[track.ID]
+		if !ok || !old.Downloaded || old.FilePath == "" {
+			continue
+		}
+		if _, err := os.Stat(old.FilePath); err != nil {
+			continue
+		}
+		pm.Tracks[i].Downloaded = true
+		pm.Tracks[i].DownloadedAt = old.DownloadedAt
+		pm.Tracks[i].FilePath = old.FilePath
+		resumed++
+	}
+	return resumed
+}
+
+// DownloadedTrackIDs returns the IDs of tracks marked as downloaded.
+func (pm *PlaylistMetadata) DownloadedTrackIDs() map[string]bool {
+	ids := make(map[string]bool)
+	for _, track := range pm.Tracks {
+		if track.Downloaded {
+			ids[track.ID] = true
+		}
+	}
+	return ids
+}
+
+// NewTrackMetadata records track's catalog data at position index.
+func NewTrackMetadata(index int, track SearchResult) TrackMetadata {
+	return TrackMetadata{
+		Index:       index,
+		ID:          track.ID,
+		Name:        track.Name,
+		Artist:      track.ArtistName,
+		Album:       track.AlbumName,
+		TrackNumber: track.TrackNumber,
+		DiscNumber:  track.DiscNumber,
+		ISRC:        track.ISRC,
+		ReleaseDate: track.ReleaseDate,
+		Genre:       track.Genre,
+		Composer:    track.Composer,
+		Duration:    int((track.DurationMillis + 500) / 1000),
+	}
+}
+
 func CreateAlbumMetadata(album *AlbumWithTracks, sourceURL string) *PlaylistMetadata {
 	metadata := &PlaylistMetadata{
 		Type:         "album",
@@ -96,13 +173,8 @@ func CreateAlbumMetadata(album *AlbumWithTracks, sourceURL string) *PlaylistMeta
 	}
 	
 	for i, track := range album.Tracks {
-		metadata.Tracks[i] = TrackMetadata{
-			Index:      i + 1,
-			ID:         track.ID,
-			Name:       track.Name,
-			Artist:     track.ArtistName,
-			Downloaded: false,
-		}
+		metadata.Tracks[i] = NewTrackMetadata(i+1, track)
+		metadata.Duration += metadata.Tracks[i].Duration
 	}
 	
 	return metadata
@@ -122,14 +194,25 @@ func CreatePlaylistMetadata(playlist *PlaylistWithTracks, sourceURL string) *Pla
 	}
 	
 	for i, track := range playlist.Tracks {
-		metadata.Tracks[i] = TrackMetadata{
-			Index:      i + 1,
-			ID:         track.ID,
-			Name:       track.Name,
-			Artist:     track.ArtistName,
-			Downloaded: false,
-		}
+		metadata.Tracks[i] = NewTrackMetadata(i+1, track)
+		metadata.Duration += metadata.Tracks[i].Duration
 	}
 	
 	return metadata
+}
+
+func CreateSongMetadata(song *SearchResult, sourceURL string) *PlaylistMetadata {
+	track := NewTrackMetadata(1, *song)
+	return &PlaylistMetadata{
+		Type:         string(song.Type),
+		ID:           song.ID,
+		Name:         song.Name,
+		Artist:       song.ArtistName,
+		TrackCount:   1,
+		ArtworkURL:   song.ArtworkURL,
+		SourceURL:    sourceURL,
+		DownloadedAt: time.Now(),
+		Duration:     track.Duration,
+		Tracks:       []TrackMetadata{track},
+	}
 }
\ No newline at end of file
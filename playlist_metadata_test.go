@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlaylistMetadataResumeFrom(t *testing.T) {
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "kept.mp3")
+	if err := os.WriteFile(kept, []byte("audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := &PlaylistMetadata{ID: "pl.1", Tracks: []TrackMetadata{
+		{ID: "1", Downloaded: true, FilePath: kept},
+		{ID: "2", Downloaded: true, FilePath: filepath.Join(dir, "deleted.mp3")},
+		{ID: "3", Error: "download failed"},
+	}}
+	fresh := &PlaylistMetadata{ID: "pl.1", Tracks: []TrackMetadata{
+		{Index: 1, ID: "1"},
+		{Index: 2, ID: "2"},
+		{Index: 3, ID: "3"},
+		{Index: 4, ID: "4"},
+	}}
+
+	if resumed := fresh.ResumeFrom(saved); resumed != 1 {
+		t.Errorf("ResumeFrom() = %d; want 1", resumed)
+	}
+	downloaded := fresh.DownloadedTrackIDs()
+	if len(downloaded) != 1 || !downloaded["1"] {
+		t.Errorf("DownloadedTrackIDs() = %v; want only track 1", downloaded)
+	}
+	if fresh.Tracks[0].FilePath != kept {
+		t.Errorf("FilePath = %q; want %q", fresh.Tracks[0].FilePath, kept)
+	}
+}
+
+func TestSaveAndLoadPlaylistMetadata(t *testing.T) {
+	dir := t.TempDir()
+	metadata := &PlaylistMetadata{ID: "1", Name: "AC/DC: Live", Tracks: []TrackMetadata{{ID: "1"}}}
+	metadata.UpdateTrackStatus("1", true, "/music/track.mp3", nil)
+
+	if err := SavePlaylistMetadata(metadata, dir); err != nil {
+		t.Fatalf("SavePlaylistMetadata returned an unexpected error: %v", err)
+	}
+	loaded, err := LoadPlaylistMetadata(MetadataFilePath(metadata, dir))
+	if err != nil {
+		t.Fatalf("LoadPlaylistMetadata returned an unexpected error: %v", err)
+	}
+	if !loaded.Tracks[0].Downloaded || loaded.Tracks[0].FilePath != "/music/track.mp3" {
+		t.Errorf("loaded track = %+v; want downloaded to /music/track.mp3", loaded.Tracks[0])
+	}
+}
+
+func TestUpdateTrackStatusStoresAbsolutePath(t *testing.T) {
+	metadata := &PlaylistMetadata{Tracks: []TrackMetadata{{ID: "1"}}}
+	metadata.UpdateTrackStatus("1", true, filepath.Join("music", "track.mp3"), nil)
+
+	want, err := filepath.Abs(filepath.Join("music", "track.mp3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := metadata.Tracks[0].FilePath; got != want {
+		t.Errorf("FilePath = %q; want %q", got, want)
+	}
+}
+
+func TestNewTrackMetadata(t *testing.T) {
+	track := SearchResult{
+		ID:             "1",
+		Name:           "Song",
+		ArtistName:     "Artist",
+		AlbumName:      "Album",
+		TrackNumber:    4,
+		DiscNumber:     2,
+		ISRC:           "USABC2000001",
+		DurationMillis: 215600,
+	}
+	got := NewTrackMetadata(3, track)
+	if got.Index != 3 || got.Album != "Album" || got.TrackNumber != 4 || got.DiscNumber != 2 || got.ISRC != "USABC2000001" {
+		t.Errorf("NewTrackMetadata() = %+v; want the track's catalog fields", got)
+	}
+	if got.Duration != 216 {
+		t.Errorf("Duration = %d; want 216", got.Duration)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAppleMusicResource(t *testing.T) {
+	response := &SonglinkResponse{
+		LinksByPlatform: LinksByPlatform{
+			"spotify":    {URL: "https://open.spotify.com/album/0ETFjACtuP2ADo6LFhL6HN", EntityUniqueID: "SPOTIFY_ALBUM::0ETFjACtuP2ADo6LFhL6HN"},
+			"appleMusic": {URL: "https://music.apple.com/gb/album/abbey-road/401469823", EntityUniqueID: "ITUNES_ALBUM::401469823"},
+		},
+		EntitiesByUniqueID: map[string]SonglinkEntity{
+			"ITUNES_ALBUM::401469823": {ID: "401469823", Type: "album"},
+		},
+	}
+
+	resource, err := appleMusicResource(response)
+	if err != nil {
+		t.Fatalf("appleMusicResource returned an unexpected error: %v", err)
+	}
+	if resource.Type != ParsedAlbum || resource.ID != "401469823" || resource.Storefront != "gb" {
+		t.Errorf("appleMusicResource() = %+v; want album 401469823 in gb", resource)
+	}
+
+	delete(response.LinksByPlatform, "appleMusic")
+	if _, err := appleMusicResource(response); err == nil {
+		t.Error("appleMusicResource() succeeded without an Apple Music link")
+	}
+}
+
+func TestPlaylistURLParserParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		wantType   ParseResourceType
+		wantID     string
+		storefront string
+		wantErr    bool
+	}{
+		{"album", "https://music.apple.com/us/album/abbey-road-remastered/1441164426", ParsedAlbum, "1441164426", "us", false},
+		{"album track", "https://music.apple.com/fi/album/caravan/1572919347?i=1572919354", ParsedSong, "1572919354", "fi", false},
+		{"album track with extra query", "https://music.apple.com/gb/album/come-together/1441164426?i=1441164430&l=en", ParsedSong, "1441164430", "gb", false},
+		{"song", "https://music.apple.com/us/song/come-together/1441164430", ParsedSong, "1441164430", "us", false},
+		{"playlist", "https://music.apple.com/us/playlist/top-100-global/pl.d25f5d1181894928af76c85c967f8f31", ParsedPlaylist, "pl.d25f5d1181894928af76c85c967f8f31", "us", false},
+		{"artist", "https://music.apple.com/jp/artist/the-beatles/136975", ParsedArtist, "136975", "jp", false},
+		{"music video", "https://music.apple.com/us/music-video/bad-guy/1473494473", ParsedMusicVideo, "1473494473", "us", false},
+		{"slug-less album", "https://music.apple.com/de/album/1441164426", ParsedAlbum, "1441164426", "de", false},
+		{"slug-less song", "https://music.apple.com/us/song/1441164430", ParsedSong, "1441164430", "us", false},
+		{"geo host", "https://geo.music.apple.com/us/album/_/1443155637?i=1443155970&mt=1&app=music", ParsedSong, "1443155970", "us", false},
+		{"itunes album", "https://itunes.apple.com/us/album/abbey-road/id401469823", ParsedAlbum, "401469823", "us", false},
+		{"itunes track", "https://itunes.apple.com/gb/album/something/id401469823?i=401469832&uo=4", ParsedSong, "401469832", "gb", false},
+		{"trailing slash", "https://music.apple.com/us/album/abbey-road/401469823/", ParsedAlbum, "401469823", "us", false},
+		{"library playlist", "https://music.apple.com/library/playlist/p.abcdef", "", "", "", true},
+		{"unknown kind", "https://music.apple.com/us/station/the-beatles-station/ra.136975", "", "", "", true},
+		{"missing ID", "https://music.apple.com/us/album/abbey-road", "", "", "", true},
+		{"bad playlist ID", "https://music.apple.com/us/playlist/mix/12345", "", "", "", true},
+	}
+
+	parser := NewPlaylistURLParser()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource, err := parser.Parse(tt.url)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %+v; want an error", tt.url, resource)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) returned an unexpected error: %v", tt.url, err)
+			}
+			if resource.Type != tt.wantType || resource.ID != tt.wantID || resource.Storefront != tt.storefront {
+				t.Errorf("Parse(%q) = %+v; want %s %s in %s", tt.url, resource, tt.wantType, tt.wantID, tt.storefront)
+			}
+		})
+	}
+}
+
+func TestPlaylistURLParserParseDefaultStorefront(t *testing.T) {
+	parser := NewPlaylistURLParser()
+	parser.defaultStorefront = func() string { return "de" }
+
+	resource, err := parser.Parse("https://music.apple.com/album/abbey-road/401469823")
+	if err != nil {
+		t.Fatalf("Parse returned an unexpected error: %v", err)
+	}
+	if resource.Storefront != "de" {
+		t.Errorf("Storefront = %q; want %q", resource.Storefront, "de")
+	}
+}
+
+func TestPlaylistURLParserParseNonApple(t *testing.T) {
+	for _, u := range []string{
+		"https://open.spotify.com/album/0ETFjACtuP2ADo6LFhL6HN",
+		"https://music.youtube.com/watch?v=abc",
+		"https://fakemusic.apple.com.example.com/us/album/x/1",
+	} {
+		if _, err := NewPlaylistURLParser().Parse(u); !errors.Is(err, ErrNotAppleMusicURL) {
+			t.Errorf("Parse(%q) error = %v; want ErrNotAppleMusicURL", u, err)
+		}
+	}
+}
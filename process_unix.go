@@ -0,0 +1,24 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// commandContext is exec.CommandContext for yt-dlp and ffmpeg. The command
+// runs in its own process group so that cancelling ctx stops the helpers it
+// spawns too (yt-dlp runs ffmpeg for post-processing); they get SIGTERM and a
+// few seconds to clean up before being killed.
+func commandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+	}
+	cmd.WaitDelay = 5 * time.Second
+	return cmd
+}
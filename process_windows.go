@@ -0,0 +1,17 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// commandContext is exec.CommandContext for yt-dlp and ffmpeg. Windows has
+// no process groups to signal, so cancelling ctx kills the process.
+func commandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = 5 * time.Second
+	return cmd
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const boardRefresh = 250 * time.Millisecond
+
+// ProgressBoard redraws a status board below the per-track result lines of a
+// batch download: an overall bar with ETA and one line per track being
+// downloaded. It is only used on terminals; elsewhere runBatchDownload prints
+// plain lines.
+type ProgressBoard struct {
+	out     io.Writer
+	tracker *ProgressTracker
+	total   int
+	width   int
+
+	mu    sync.Mutex
+	lines int
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// liveTerminal returns ui() when it is a terminal that output can be redrawn
+// on, which isn't the case while debug output is interleaved.
+func liveTerminal(debug bool) (*os.File, bool) {
+	f, ok := ui().(*os.File)
+	if debug || !ok || !isTerminal(f) || os.Getenv("TERM") == "dumb" {
+		return nil, false
+	}
+	return f, true
+}
+
+// NewProgressBoard returns a board for total tracks, or nil when ui() isn't a
+// live terminal.
+func NewProgressBoard(tracker *ProgressTracker, total int, debug bool) *ProgressBoard {
+	f, ok := liveTerminal(debug)
+	if !ok {
+		return nil
+	}
+	return &ProgressBoard{
+		out:     f,
+		tracker: tracker,
+		total:   total,
+		width:   terminalWidth(f),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start redraws the board periodically until Stop is called.
+func (b *ProgressBoard) Start() {
+	go func() {
+		defer close(b.done)
+		ticker := time.NewTicker(boardRefresh)
+		defer ticker.Stop()
+		for {
+			b.mu.Lock()
+			b.redraw()
+			b.mu.Unlock()
+			select {
+			case <-b.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Println prints a line above the board.
+func (b *ProgressBoard) Println(line string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clear()
+	fmt.Fprintln(b.out, line)
+	b.redraw()
+}
+
+// Stop removes the board from the screen.
+func (b *ProgressBoard) Stop() {
+	close(b.stop)
+	<-b.done
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clear()
+}
+
+func (b *ProgressBoard) clear() {
+	if b.lines > 0 {
+		fmt.Fprintf(b.out, "\x1b[%dA\x1b[J", b.lines)
+		b.lines = 0
+	}
+}
+
+func (b *ProgressBoard) redraw() {
+	b.clear()
+	lines := b.render(time.Now())
+	for _, line := range lines {
+		fmt.Fprintln(b.out, line)
+	}
+	b.lines = len(lines)
+}
+
+func (b *ProgressBoard) render(now time.Time) []string {
+	_, completed, failed := b.tracker.GetStats()
+	finished := int(completed + failed + b.tracker.Cancelled())
+	elapsed := b.tracker.Elapsed()
+
+	lines := []string{overallLine(finished, b.total, elapsed, b.width)}
+	for _, track := range b.tracker.ActiveTracks() {
+		lines = append(lines, trackLine(track, now, b.width))
+	}
+	return lines
+}
+
+func overallLine(finished, total int, elapsed time.Duration, width int) string {
+	fraction := 0.0
+	if total > 0 {
+		fraction = float64(finished) / float64(total)
+	}
+
+	eta := "--"
+	if finished > 0 && finished < total {
+		remaining := time.Duration(float64(elapsed) / float64(finished) * float64(total-finished))
+		eta = remaining.Round(time.Second).String()
+	}
+
+	stats := fmt.Sprintf(" %d/%d tracks %3.0f%%  elapsed %s  ETA %s", finished, total, fraction*100, elapsed.Round(time.Second), eta)
+	barWidth := min(40, width-utf8.RuneCountInString(stats)-2)
+	if barWidth < 10 {
+		return strings.TrimSpace(stats)
+	}
+	filled := int(fraction * float64(barWidth))
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", barWidth-filled) + "]" + stats
+}
+
+func trackLine(track TrackProgress, now time.Time, width int) string {
+	status := string(track.Status)
+	if track.Status == StatusRetrying {
+		status = fmt.Sprintf("retry %d", track.RetryCount)
+	}
+	percent := ""
+	if p := track.Download.Percent(); p > 0 {
+		percent = fmt.Sprintf("%5.1f%%", p)
+	}
+	detail := fmt.Sprintf("  %-11s %6s %6s", status, now.Sub(track.StartTime).Round(time.Second), percent)
+	nameWidth := width - utf8.RuneCountInString(detail) - 2
+	return fmt.Sprintf("  %-*s%s", nameWidth, truncate(track.Artist+" - "+track.Name, nameWidth), detail)
+}
+
+// truncate shortens s to at most n runes, ending it with "…" when cut.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	if n <= 1 {
+		return "…"
+	}
+	runes := []rune(s)
+	return string(runes[:n-1]) + "…"
+}
+
+// ProgressLine shows a single download's progress after a label such as
+// "Downloading... ", rewriting the line in place on a terminal.
+type ProgressLine struct {
+	label string
+	out   *os.File
+}
+
+// NewProgressLine prints label and returns the line to update.
+func NewProgressLine(label string, debug bool) *ProgressLine {
+	out, _ := liveTerminal(debug)
+	fmt.Fprint(ui(), label)
+	return &ProgressLine{label: label, out: out}
+}
+
+// Update shows p after the label. It does nothing when not on a terminal.
+func (l *ProgressLine) Update(p DownloadProgress) {
+	if l.out != nil {
+		fmt.Fprintf(l.out, "\r\x1b[K%s%s", l.label, p)
+	}
+}
+
+// Done replaces the progress with msg and ends the line.
+func (l *ProgressLine) Done(msg string) {
+	if l.out != nil {
+		fmt.Fprintf(l.out, "\r\x1b[K%s", l.label)
+	}
+	fmt.Fprintln(ui(), msg)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestOverallLine(t *testing.T) {
+	line := overallLine(5, 20, 50*time.Second, 100)
+	for _, want := range []string{"[##########------------------------------]", "5/20 tracks", "25%", "elapsed 50s", "ETA 2m30s"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("overallLine() = %q; want it to contain %q", line, want)
+		}
+	}
+
+	if line := overallLine(0, 20, time.Second, 100); !strings.Contains(line, "ETA --") {
+		t.Errorf("overallLine() before any track finished = %q; want ETA --", line)
+	}
+}
+
+func TestTrackLine(t *testing.T) {
+	now := time.Now()
+	track := TrackProgress{
+		Artist:     "The Beatles",
+		Name:       "While My Guitar Gently Weeps (Remastered 2009, Anniversary Edition)",
+		Status:     StatusRetrying,
+		RetryCount: 2,
+		StartTime:  now.Add(-12 * time.Second),
+		Download:   DownloadProgress{Downloaded: 425, Total: 1000},
+	}
+	line := trackLine(track, now, 80)
+	if n := utf8.RuneCountInString(line); n != 80 {
+		t.Errorf("trackLine() is %d runes wide; want 80: %q", n, line)
+	}
+	for _, want := range []string{"The Beatles - While My", "…", "retry 2", "12s", "42.5%"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("trackLine() = %q; want it to contain %q", line, want)
+		}
+	}
+}
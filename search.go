@@ -10,14 +10,16 @@ import (
 
 	"github.com/guitaripod/musickitkat"
 	"github.com/guitaripod/musickitkat/auth"
+	"github.com/guitaripod/musickitkat/models"
 )
 
 type SearchType string
 
 const (
-	Song  SearchType = "song"
-	Album SearchType = "album"
-	Both  SearchType = "both"
+	Song       SearchType = "song"
+	Album      SearchType = "album"
+	Both       SearchType = "both"
+	MusicVideo SearchType = "music-video"
 )
 
 type MusicSearcher struct {
@@ -25,12 +27,26 @@ type MusicSearcher struct {
 }
 
 type SearchResult struct {
-	ID         string
-	Name       string
-	ArtistName string
-	Type       SearchType
-	URL        string
-	ArtworkURL string
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	ArtistName     string     `json:"artist_name"`
+	Type           SearchType `json:"type"`
+	URL            string     `json:"url"`
+	ArtworkURL     string     `json:"artwork_url"`
+	ISRC           string     `json:"isrc,omitempty"`
+	DurationMillis int64      `json:"duration_millis,omitempty"`
+	AlbumName      string     `json:"album_name,omitempty"`
+	AlbumArtist    string     `json:"album_artist,omitempty"`
+	TrackNumber    int        `json:"track_number,omitempty"`
+	DiscNumber     int        `json:"disc_number,omitempty"`
+	TrackCount     int        `json:"track_count,omitempty"` // tracks on the album, when known
+	ReleaseDate    string     `json:"release_date,omitempty"`
+	Genre          string     `json:"genre,omitempty"`
+	Composer       string     `json:"composer,omitempty"`
+	ContentRating  string     `json:"content_rating,omitempty"`
+	// FullArtworkURL is the artwork at its original resolution, for
+	// embedding in tags.
+	FullArtworkURL string `json:"full_artwork_url,omitempty"`
 }
 
 func NewMusicSearcher(config *Config) (*MusicSearcher, error) {
@@ -80,32 +96,26 @@ func (ms *MusicSearcher) Search(ctx context.Context, query string, searchType Se
 
 		if st == string(musickitkat.SearchTypesSongs) && len(searchResults.Results.Songs.Data) > 0 {
 			for _, song := range searchResults.Results.Songs.Data {
-				artURL := song.Attributes.Artwork.URL
-				artURL = strings.ReplaceAll(artURL, "{w}", "500")
-				artURL = strings.ReplaceAll(artURL, "{h}", "500")
-				results = append(results, SearchResult{
-					ID:         song.ID,
-					Name:       song.Attributes.Name,
-					ArtistName: song.Attributes.ArtistName,
-					Type:       Song,
-					URL:        song.Attributes.URL,
-					ArtworkURL: artURL,
-				})
+				results = append(results, NewSongResult(song))
 			}
 		}
 
 		if st == string(musickitkat.SearchTypesAlbums) && len(searchResults.Results.Albums.Data) > 0 {
 			for _, album := range searchResults.Results.Albums.Data {
-				artURL := album.Attributes.Artwork.URL
-				artURL = strings.ReplaceAll(artURL, "{w}", "500")
-				artURL = strings.ReplaceAll(artURL, "{h}", "500")
 				results = append(results, SearchResult{
-					ID:         album.ID,
-					Name:       album.Attributes.Name,
-					ArtistName: album.Attributes.ArtistName,
-					Type:       Album,
-					URL:        album.Attributes.URL,
-					ArtworkURL: artURL,
+					ID:             album.ID,
+					Name:           album.Attributes.Name,
+					ArtistName:     album.Attributes.ArtistName,
+					Type:           Album,
+					URL:            album.Attributes.URL,
+					ArtworkURL:     artworkURL(album.Attributes.Artwork.URL, 500),
+					AlbumName:      album.Attributes.Name,
+					AlbumArtist:    album.Attributes.ArtistName,
+					TrackCount:     album.Attributes.TrackCount,
+					ReleaseDate:    album.Attributes.ReleaseDate,
+					Genre:          primaryGenre(album.Attributes.GenreNames),
+					ContentRating:  album.Attributes.ContentRating,
+					FullArtworkURL: fullArtworkURL(album.Attributes.Artwork),
 				})
 			}
 		}
@@ -114,30 +124,83 @@ func (ms *MusicSearcher) Search(ctx context.Context, query string, searchType Se
 	return results, nil
 }
 
+// NewSongResult converts an Apple Music catalog song to a SearchResult.
+func NewSongResult(song models.Song) SearchResult {
+	return SearchResult{
+		ID:             song.ID,
+		Name:           song.Attributes.Name,
+		ArtistName:     song.Attributes.ArtistName,
+		Type:           Song,
+		URL:            song.Attributes.URL,
+		ArtworkURL:     artworkURL(song.Attributes.Artwork.URL, 500),
+		ISRC:           song.Attributes.ISRC,
+		DurationMillis: song.Attributes.DurationInMillis,
+		AlbumName:      song.Attributes.AlbumName,
+		TrackNumber:    song.Attributes.TrackNumber,
+		DiscNumber:     song.Attributes.DiscNumber,
+		ReleaseDate:    song.Attributes.ReleaseDate,
+		Genre:          primaryGenre(song.Attributes.GenreNames),
+		Composer:       song.Attributes.Composer,
+		ContentRating:  song.Attributes.ContentRating,
+		FullArtworkURL: fullArtworkURL(song.Attributes.Artwork),
+	}
+}
+
+// primaryGenre returns the first genre other than the catch-all "Music".
+func primaryGenre(genres []string) string {
+	for _, genre := range genres {
+		if genre != "Music" {
+			return genre
+		}
+	}
+	return ""
+}
+
+// artworkURL fills in the {w}x{h} placeholders of an Apple Music artwork URL
+// template.
+func artworkURL(template string, size int) string {
+	dimension := fmt.Sprintf("%d", size)
+	url := strings.ReplaceAll(template, "{w}", dimension)
+	return strings.ReplaceAll(url, "{h}", dimension)
+}
+
+// fullArtworkURL returns the artwork at the size Apple Music stores it.
+func fullArtworkURL(artwork models.Artwork) string {
+	if artwork.URL == "" {
+		return ""
+	}
+	width, height := artwork.Width, artwork.Height
+	if width <= 0 || height <= 0 {
+		width, height = 3000, 3000
+	}
+	url := strings.ReplaceAll(artwork.URL, "{w}", fmt.Sprintf("%d", width))
+	return strings.ReplaceAll(url, "{h}", fmt.Sprintf("%d", height))
+}
+
 func DisplaySearchResults(results []SearchResult) (*SearchResult, error) {
 	if len(results) == 0 {
 		return nil, errors.New("no results found")
 	}
 
-	fmt.Println("\nSearch Results:")
-	fmt.Println("----------------")
+	fmt.Fprintln(ui(), "\nSearch Results:")
+	fmt.Fprintln(ui(), "----------------")
 
 	for i, result := range results {
 		typeStr := "Song"
 		if result.Type == Album {
 			typeStr = "Album"
 		}
-		fmt.Printf("%d. [%s] %s - %s\n", i+1, typeStr, result.Name, result.ArtistName)
+		fmt.Fprintf(ui(), "%d. [%s] %s - %s\n", i+1, typeStr, result.Name, result.ArtistName)
 	}
 
 	var choice int
-	fmt.Print("\nSelect a result (1-", len(results), "): ")
+	fmt.Fprint(ui(), "\nSelect a result (1-", len(results), "): ")
 	
 	var input string
 	fmt.Scanln(&input)
 	
 	if input == "" {
-		fmt.Println("1 (automatic selection)")
+		fmt.Fprintln(ui(), "1 (automatic selection)")
 		choice = 1
 	} else {
 		_, err := fmt.Sscanf(input, "%d", &choice)
@@ -149,14 +212,14 @@ func DisplaySearchResults(results []SearchResult) (*SearchResult, error) {
 	return &results[choice-1], nil
 }
 
-func HandleSearch(query string, searchType SearchType, outDir string, debug bool) error {
+func HandleSearch(query string, searchType SearchType, outDir, nameTemplate string, downloader *Downloader, debug bool) error {
 	config, err := LoadConfig()
 	if err != nil {
 		return fmt.Errorf("error loading config: %w", err)
 	}
 
 	if !config.ConfigExists {
-		fmt.Println("Apple Music API credentials not found. Let's set them up.")
+		fmt.Fprintln(ui(), "Apple Music API credentials not found. Let's set them up.")
 		err = RunOnboarding()
 		if err != nil {
 			return fmt.Errorf("error during onboarding: %w", err)
@@ -173,17 +236,22 @@ func HandleSearch(query string, searchType SearchType, outDir string, debug bool
 		return fmt.Errorf("error creating music searcher: %w", err)
 	}
 
-	stopLoading := make(chan bool)
-	go func() {
-		loadingIndicator(stopLoading)
-	}()
+	var stopLoading chan bool
+	if !*jsonFlag {
+		stopLoading = make(chan bool)
+		go func() {
+			loadingIndicator(stopLoading)
+		}()
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	results, err := searcher.Search(ctx, query, searchType)
 
-	stopLoading <- true
+	if stopLoading != nil {
+		stopLoading <- true
+	}
 
 	if err != nil {
 		return fmt.Errorf("error searching: %w", err)
@@ -194,100 +262,103 @@ func HandleSearch(query string, searchType SearchType, outDir string, debug bool
 		return fmt.Errorf("error selecting result: %w", err)
 	}
 
-   fmt.Printf("\nSelected: %s - %s\n", selected.Name, selected.ArtistName)
-   fmt.Println("\nWhat would you like to do?")
-   fmt.Println("1) Copy song.link + Spotify URL to clipboard")
-   fmt.Println("2) Download MP3")
-   fmt.Println("3) Download MP4 (video with artwork)")
-   fmt.Print("Enter choice (1-3, default 1): ")
+   fmt.Fprintf(ui(), "\nSelected: %s - %s\n", selected.Name, selected.ArtistName)
+   fmt.Fprintln(ui(), "\nWhat would you like to do?")
+   fmt.Fprintln(ui(), "1) Copy song.link + Spotify URL to clipboard")
+   fmt.Fprintln(ui(), "2) Download MP3")
+   fmt.Fprintln(ui(), "3) Download MP4 (video with artwork)")
+   fmt.Fprint(ui(), "Enter choice (1-3, default 1): ")
    var choice string
    fmt.Scanln(&choice)
+   for choice != "" && choice != "1" && choice != "2" && choice != "3" {
+       fmt.Fprintln(ui(), "Invalid choice. Please enter a valid option (1-3, default 1):")
+       fmt.Fprint(ui(), "Enter choice (1-3, default 1): ")
+       choice = ""
+       fmt.Scanln(&choice)
+   }
+
+   output := SearchOutput{Query: query, Selected: selected}
    switch choice {
    case "", "1":
-       if err := GetLinks(selected.URL); err != nil {
-           return fmt.Errorf("error getting links: %w", err)
+       ctx, stop := interruptContext()
+       links, err := GetLinks(ctx, selected.URL)
+       stop()
+       if wasInterrupted(ctx) {
+           return errInterrupted
        }
-   case "2":
-       fmt.Print("Downloading MP3... ")
-       path, err := DownloadTrack(selected.Name, selected.ArtistName, selected.ArtworkURL, "mp3", outDir, debug)
        if err != nil {
-           return fmt.Errorf("error downloading mp3: %w", err)
+           return fmt.Errorf("error getting links: %w", err)
        }
-       fmt.Printf("Done. Saved to %s\n", path)
-   case "3":
-       fmt.Print("Downloading MP4... ")
-       path, err := DownloadTrack(selected.Name, selected.ArtistName, selected.ArtworkURL, "mp4", outDir, debug)
-       if err != nil {
-           return fmt.Errorf("error downloading mp4: %w", err)
+       output.Links = links
+   case "2", "3":
+       format := "mp3"
+       if choice == "3" {
+           format = "mp4"
        }
-       fmt.Printf("Done. Saved to %s\n", path)
-   default:
-       for {
-           fmt.Println("Invalid choice. Please enter a valid option (1-3, default 1):")
-           fmt.Print("Enter choice (1-3, default 1): ")
-           fmt.Scanln(&choice)
-           if choice == "" || choice == "1" || choice == "2" || choice == "3" {
-               break
-           }
+       line := NewProgressLine(fmt.Sprintf("Downloading %s... ", strings.ToUpper(format)), debug)
+       start := time.Now()
+       ctx, stop := interruptContext()
+       path, outcome, err := DownloadTrack(ctx, *selected, format, outDir, nameTemplate, downloader, debug, line.Update)
+       stop()
+       if wasInterrupted(ctx) {
+           return errInterrupted
+       }
+       if err != nil {
+           line.Done("failed")
+           return fmt.Errorf("error downloading %s: %w", format, err)
        }
-       switch choice {
-       case "", "1":
-           if err := GetLinks(selected.URL); err != nil {
-               return fmt.Errorf("error getting links: %w", err)
-           }
-       case "2":
-           fmt.Print("Downloading MP3... ")
-           path, err := DownloadTrack(selected.Name, selected.ArtistName, selected.ArtworkURL, "mp3", outDir, debug)
-           if err != nil {
-               return fmt.Errorf("error downloading mp3: %w", err)
-           }
-           fmt.Printf("Done. Saved to %s\n", path)
-       case "3":
-           fmt.Print("Downloading MP4... ")
-           path, err := DownloadTrack(selected.Name, selected.ArtistName, selected.ArtworkURL, "mp4", outDir, debug)
-           if err != nil {
-               return fmt.Errorf("error downloading mp4: %w", err)
-           }
-           fmt.Printf("Done. Saved to %s\n", path)
+       line.Done(describeOutcome(outcome, path))
+       output.Download = &FileOutput{
+           FilePath:        path,
+           Format:          format,
+           Outcome:         outcome,
+           DurationSeconds: time.Since(start).Seconds(),
        }
    }
+
+   if *jsonFlag {
+       return printJSON(output)
+   }
    return nil
 }
 
 func RunOnboarding() error {
-	config := &Config{}
-
-	fmt.Println("\n========== Apple Music API Setup ==========")
-	fmt.Println("To use the search feature, you need Apple Music API credentials.")
-	fmt.Println("Follow these steps to get them:")
-	fmt.Println("1. Sign in to your Apple Developer account at https://developer.apple.com")
-	fmt.Println("2. Go to Certificates, Identifiers & Profiles")
-	fmt.Println("3. Under Keys, create a new key with MusicKit enabled")
-	fmt.Println("4. Note down the Key ID, Team ID, and download the private key (.p8) file")
-	fmt.Println("\nYou'll need to enter these values below:")
-
-	fmt.Print("\nTeam ID: ")
+	config, err := LoadConfig()
+	if err != nil {
+		config = &Config{}
+	}
+
+	fmt.Fprintln(ui(), "\n========== Apple Music API Setup ==========")
+	fmt.Fprintln(ui(), "To use the search feature, you need Apple Music API credentials.")
+	fmt.Fprintln(ui(), "Follow these steps to get them:")
+	fmt.Fprintln(ui(), "1. Sign in to your Apple Developer account at https://developer.apple.com")
+	fmt.Fprintln(ui(), "2. Go to Certificates, Identifiers & Profiles")
+	fmt.Fprintln(ui(), "3. Under Keys, create a new key with MusicKit enabled")
+	fmt.Fprintln(ui(), "4. Note down the Key ID, Team ID, and download the private key (.p8) file")
+	fmt.Fprintln(ui(), "\nYou'll need to enter these values below:")
+
+	fmt.Fprint(ui(), "\nTeam ID: ")
 	fmt.Scanln(&config.TeamID)
 	config.TeamID = strings.TrimSpace(config.TeamID)
 	if config.TeamID == "" {
 		return errors.New("team ID cannot be empty")
 	}
 
-	fmt.Print("Key ID: ")
+	fmt.Fprint(ui(), "Key ID: ")
 	fmt.Scanln(&config.KeyID)
 	config.KeyID = strings.TrimSpace(config.KeyID)
 	if config.KeyID == "" {
 		return errors.New("key ID cannot be empty")
 	}
 
-	fmt.Print("Music ID (usually same as Team ID): ")
+	fmt.Fprint(ui(), "Music ID (usually same as Team ID): ")
 	fmt.Scanln(&config.MusicID)
 	config.MusicID = strings.TrimSpace(config.MusicID)
 	if config.MusicID == "" {
 		config.MusicID = config.TeamID
 	}
 
-	fmt.Println("\nPath to your .p8 private key file:")
+	fmt.Fprintln(ui(), "\nPath to your .p8 private key file:")
 	var keyPath string
 	fmt.Scanln(&keyPath)
 	keyPath = strings.TrimSpace(keyPath)
@@ -306,11 +377,10 @@ func RunOnboarding() error {
 		return errors.New("private key file is empty")
 	}
 
-	err = config.SaveConfig()
-	if err != nil {
+	if err := config.SaveConfig(); err != nil {
 		return fmt.Errorf("error saving config: %w", err)
 	}
 
-	fmt.Println("\n✅ Apple Music API credentials saved successfully!")
+	fmt.Fprintln(ui(), "\n✅ Apple Music API credentials saved successfully!")
 	return nil
 }
\ No newline at end of file
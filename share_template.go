@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// ShareData is the data passed to output templates.
+type ShareData struct {
+	Title     string            `json:"title,omitempty"`
+	Artist    string            `json:"artist,omitempty"`
+	Type      string            `json:"type,omitempty"`
+	Thumbnail string            `json:"thumbnail,omitempty"`
+	PageURL   string            `json:"page_url"`
+	Links     map[string]string `json:"links"`
+	Platforms []string          `json:"platforms"`
+}
+
+var builtinTemplates = map[string]string{
+	"slack":    "<{{.PageURL}}|{{.Artist}} – {{.Title}}>",
+	"markdown": "[{{.Artist}} - {{.Title}}]({{.PageURL}})",
+	"irc":      "{{.Artist}} - {{.Title}} :: {{.PageURL}}",
+	"mastodon": "🎵 {{.Artist}} – {{.Title}}\n{{.PageURL}}",
+}
+
+var platformDisplayNames = map[string]string{
+	"spotify":      "Spotify",
+	"appleMusic":   "Apple Music",
+	"itunes":       "iTunes",
+	"youtube":      "YouTube",
+	"youtubeMusic": "YouTube Music",
+	"tidal":        "Tidal",
+	"deezer":       "Deezer",
+	"amazonMusic":  "Amazon Music",
+	"amazonStore":  "Amazon",
+	"soundcloud":   "SoundCloud",
+	"bandcamp":     "Bandcamp",
+	"pandora":      "Pandora",
+	"napster":      "Napster",
+	"yandex":       "Yandex Music",
+	"audiomack":    "Audiomack",
+	"anghami":      "Anghami",
+	"boomplay":     "Boomplay",
+	"audius":       "Audius",
+	"spinrilla":    "Spinrilla",
+}
+
+func platformName(platform string) string {
+	if name, ok := platformDisplayNames[platform]; ok {
+		return name
+	}
+	return platform
+}
+
+var templateFuncs = template.FuncMap{
+	"join":         strings.Join,
+	"upper":        strings.ToUpper,
+	"lower":        strings.ToLower,
+	"platformName": platformName,
+}
+
+func NewShareData(linksResponse *SonglinkResponse) ShareData {
+	data := ShareData{
+		PageURL:   shareablePageURL(linksResponse.PageURL),
+		Links:     make(map[string]string),
+		Platforms: linksResponse.LinksByPlatform.Platforms(),
+	}
+
+	for platform, link := range linksResponse.LinksByPlatform {
+		data.Links[platform] = link.URL
+	}
+
+	if entity, ok := linksResponse.Entity(); ok {
+		data.Title = entity.Title
+		data.Artist = entity.ArtistName
+		data.Type = entity.Type
+		data.Thumbnail = entity.ThumbnailURL
+	}
+
+	return data
+}
+
+// resolveTemplate returns the template text for name. Names are looked up in
+// the config file first and then in the built-in templates; anything
+// containing "{{" is used as an inline template.
+func resolveTemplate(name string) (string, error) {
+	if strings.Contains(name, "{{") {
+		return name, nil
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return "", fmt.Errorf("error loading config: %w", err)
+	}
+	if text, ok := config.Templates[name]; ok {
+		return text, nil
+	}
+	if text, ok := builtinTemplates[name]; ok {
+		return text, nil
+	}
+
+	return "", fmt.Errorf("unknown template %q (available: %s)", name, strings.Join(templateNames(config), ", "))
+}
+
+func templateNames(config *Config) []string {
+	var names []string
+	for name := range builtinTemplates {
+		if _, ok := config.Templates[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	for name := range config.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func RenderTemplate(text string, data ShareData) (string, error) {
+	tmpl, err := template.New("share").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("error executing template: %w", err)
+	}
+
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
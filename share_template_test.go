@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestRenderTemplate(t *testing.T) {
+	response := &SonglinkResponse{
+		EntityUniqueID: "ITUNES_SONG::1572919354",
+		PageURL:        "https://song.link/i/1572919354",
+		LinksByPlatform: LinksByPlatform{
+			"spotify": {URL: "https://open.spotify.com/track/2Xtsv7BUMrNodQWH2JPOc0"},
+			"tidal":   {URL: "https://listen.tidal.com/track/189838410"},
+		},
+		EntitiesByUniqueID: map[string]SonglinkEntity{
+			"ITUNES_SONG::1572919354": {Title: "Caravan", ArtistName: "Cécile McLorin Salvant"},
+		},
+	}
+	data := NewShareData(response)
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"builtin markdown", builtinTemplates["markdown"], "[Cécile McLorin Salvant - Caravan](https://song.link/i/1572919354)"},
+		{"platform link", "{{.Links.tidal}}", "https://listen.tidal.com/track/189838410"},
+		{"missing platform", "{{.Links.deezer}}", ""},
+		{"range platforms", "{{range .Platforms}}{{platformName .}};{{end}}", "Spotify;Tidal;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := RenderTemplate(tt.template, data)
+			if err != nil {
+				t.Fatalf("RenderTemplate(%q) returned an unexpected error: %v", tt.template, err)
+			}
+			if output != tt.expected {
+				t.Errorf("RenderTemplate(%q) = %q; want %q", tt.template, output, tt.expected)
+			}
+		})
+	}
+}
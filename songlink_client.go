@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// song.link allows 10 requests per minute without an API key.
+	defaultSonglinkRequestsPerMinute = 10
+	defaultSonglinkTimeout           = 15 * time.Second
+	defaultSonglinkMaxRetries        = 4
+	maxRetryAfter                    = 2 * time.Minute
+)
+
+// SonglinkClient talks to the song.link API. It rate limits requests with a
+// token bucket and retries 429 and 5xx responses, honouring Retry-After.
+type SonglinkClient struct {
+	APIKey      string
+	Options     LookupOptions
+	HTTPClient  *http.Client
+	MaxRetries  int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+
+	limiter *tokenBucket
+	cache   *ResponseCache
+}
+
+func NewSonglinkClient(apiKey string, requestsPerMinute int) *SonglinkClient {
+	if requestsPerMinute <= 0 {
+		requestsPerMinute = defaultSonglinkRequestsPerMinute
+	}
+
+	return &SonglinkClient{
+		APIKey: apiKey,
+		HTTPClient: &http.Client{
+			Timeout: defaultSonglinkTimeout,
+		},
+		MaxRetries:  defaultSonglinkMaxRetries,
+		BaseBackoff: time.Second,
+		MaxBackoff:  time.Minute,
+		limiter:     newTokenBucket(requestsPerMinute, time.Minute),
+		cache:       responseCache(),
+	}
+}
+
+// LookupOptions are the optional song.link query parameters.
+type LookupOptions struct {
+	// UserCountry is the ISO 3166-1 alpha-2 code of the regional catalog
+	// to resolve links in.
+	UserCountry string
+	// SongIfSingle resolves a single's album URL to the song itself.
+	SongIfSingle bool
+}
+
+var (
+	countryFlag      = flag.String("country", "", "Country code of the catalog to resolve links in (default: config country, or US)")
+	songIfSingleFlag = flag.Bool("song-if-single", false, "Resolve links to a single's album to the song instead")
+)
+
+// registerLookupFlags makes the song.link lookup flags available on a
+// subcommand's flag set.
+func registerLookupFlags(fs *flag.FlagSet) {
+	fs.StringVar(countryFlag, "country", *countryFlag, "Country code of the catalog to resolve links in")
+	fs.BoolVar(songIfSingleFlag, "song-if-single", *songIfSingleFlag, "Resolve links to a single's album to the song instead")
+}
+
+var countryCodePattern = regexp.MustCompile(`^[A-Za-z]{2}$`)
+
+// lookupOptionsFromFlags combines the lookup flags with the defaults from
+// config.json.
+func lookupOptionsFromFlags(config *Config) (LookupOptions, error) {
+	opts := LookupOptions{
+		UserCountry:  *countryFlag,
+		SongIfSingle: *songIfSingleFlag,
+	}
+	if config != nil {
+		if opts.UserCountry == "" {
+			opts.UserCountry = config.Country
+		}
+		opts.SongIfSingle = opts.SongIfSingle || config.SongIfSingle
+	}
+
+	if opts.UserCountry != "" {
+		if !countryCodePattern.MatchString(opts.UserCountry) {
+			return opts, fmt.Errorf("invalid country code %q (expected two letters, e.g. US)", opts.UserCountry)
+		}
+		opts.UserCountry = strings.ToUpper(opts.UserCountry)
+	}
+
+	return opts, nil
+}
+
+var (
+	sharedSonglinkClient     *SonglinkClient
+	sharedSonglinkClientErr  error
+	sharedSonglinkClientOnce sync.Once
+)
+
+// defaultSonglinkClient returns a client configured from config.json and the
+// lookup flags, shared so that every caller draws from the same rate limit.
+func defaultSonglinkClient() (*SonglinkClient, error) {
+	sharedSonglinkClientOnce.Do(func() {
+		var apiKey string
+		var requestsPerMinute int
+		config, err := LoadConfig()
+		if err == nil {
+			apiKey = config.SonglinkAPIKey
+			requestsPerMinute = config.SonglinkRequestsPerMinute
+		}
+		sharedSonglinkClient = NewSonglinkClient(apiKey, requestsPerMinute)
+		sharedSonglinkClient.Options, sharedSonglinkClientErr = lookupOptionsFromFlags(config)
+	})
+	return sharedSonglinkClient, sharedSonglinkClientErr
+}
+
+// lookupURL is the API URL for searchURL without the API key, which also
+// serves as the cache key.
+func (c *SonglinkClient) lookupURL(searchURL string) string {
+	values := url.Values{}
+	if c.Options.UserCountry != "" {
+		values.Set("userCountry", c.Options.UserCountry)
+	}
+	if c.Options.SongIfSingle {
+		values.Set("songIfSingle", "true")
+	}
+
+	lookupURL := buildURL(searchURL)
+	if len(values) > 0 {
+		lookupURL += "&" + values.Encode()
+	}
+	return lookupURL
+}
+
+func (c *SonglinkClient) requestURL(searchURL string) string {
+	requestURL := c.lookupURL(searchURL)
+	if c.APIKey != "" {
+		requestURL += "&" + url.Values{"key": {c.APIKey}}.Encode()
+	}
+	return requestURL
+}
+
+// Lookup resolves searchURL to its links on every platform.
+func (c *SonglinkClient) Lookup(ctx context.Context, searchURL string) (*SonglinkResponse, error) {
+	body, err := c.fetch(ctx, searchURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var linksResponse SonglinkResponse
+	if err := json.Unmarshal(body, &linksResponse); err != nil {
+		return nil, fmt.Errorf("error decoding JSON response: %w", err)
+	}
+
+	return &linksResponse, nil
+}
+
+func (c *SonglinkClient) fetch(ctx context.Context, searchURL string) ([]byte, error) {
+	cacheKey := "songlink|" + c.lookupURL(normalizeCacheURL(searchURL))
+	if body, ok := c.cache.Get(cacheKey); ok {
+		return body, nil
+	}
+
+	body, err := c.get(ctx, c.requestURL(searchURL))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.cache != nil {
+		if err := c.cache.Put(cacheKey, body, c.cache.songlinkTTL); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to cache response: %v\n", err)
+		}
+	}
+
+	return body, nil
+}
+
+func (c *SonglinkClient) get(ctx context.Context, requestURL string) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating HTTP request: %w", err)
+		}
+
+		delay := c.backoff(attempt)
+		resp, err := c.HTTPClient.Do(req)
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = fmt.Errorf("error making HTTP request: %w", err)
+		case resp.StatusCode == http.StatusOK:
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("error reading HTTP response: %w", err)
+			}
+			return body, nil
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			resp.Body.Close()
+			lastErr = fmt.Errorf("received non-OK HTTP response status: %s", resp.Status)
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = retryAfter
+			}
+		default:
+			resp.Body.Close()
+			return nil, fmt.Errorf("received non-OK HTTP response status: %s", resp.Status)
+		}
+
+		if attempt >= c.MaxRetries {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt+1, lastErr)
+		}
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *SonglinkClient) backoff(attempt int) time.Duration {
+	delay := c.BaseBackoff << attempt
+	if delay > c.MaxBackoff || delay <= 0 {
+		delay = c.MaxBackoff
+	}
+	return delay
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as
+// an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		delay = time.Until(date)
+	} else {
+		return 0, false
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+	if delay > maxRetryAfter {
+		delay = maxRetryAfter
+	}
+	return delay, true
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// tokenBucket allows bursts of up to capacity requests and refills one
+// token every period/capacity.
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	rate     float64 // tokens per second
+	last     time.Time
+}
+
+func newTokenBucket(capacity int, period time.Duration) *tokenBucket {
+	return &tokenBucket{
+		capacity: float64(capacity),
+		tokens:   float64(capacity),
+		rate:     float64(capacity) / period.Seconds(),
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+		b.last = now
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestSonglinkClient(t *testing.T, handler http.HandlerFunc) *SonglinkClient {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	original := songlinkAPIBase
+	songlinkAPIBase = server.URL
+	t.Cleanup(func() { songlinkAPIBase = original })
+
+	client := NewSonglinkClient("", 1000)
+	client.BaseBackoff = time.Millisecond
+	client.MaxBackoff = 10 * time.Millisecond
+	return client
+}
+
+func TestSonglinkClientRetriesRateLimited(t *testing.T) {
+	var calls int32
+	client := newTestSonglinkClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"pageUrl": "https://song.link/i/1"}`)
+	})
+
+	response, err := client.Lookup(context.Background(), "https://music.apple.com/us/song/1")
+	if err != nil {
+		t.Fatalf("Lookup returned an unexpected error: %v", err)
+	}
+	if response.PageURL != "https://song.link/i/1" {
+		t.Errorf("Lookup returned page URL %q", response.PageURL)
+	}
+	if calls != 3 {
+		t.Errorf("server was called %d times; want 3", calls)
+	}
+}
+
+func TestSonglinkClientDoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	client := newTestSonglinkClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	if _, err := client.Lookup(context.Background(), "not a url"); err == nil {
+		t.Fatalf("Lookup should fail on a 400 response")
+	}
+	if calls != 1 {
+		t.Errorf("server was called %d times; want 1", calls)
+	}
+}
+
+func TestSonglinkClientGivesUp(t *testing.T) {
+	var calls int32
+	client := newTestSonglinkClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	client.MaxRetries = 2
+
+	if _, err := client.Lookup(context.Background(), "https://music.apple.com/us/song/1"); err == nil {
+		t.Fatalf("Lookup should fail when every attempt returns 502")
+	}
+	if calls != 3 {
+		t.Errorf("server was called %d times; want 3", calls)
+	}
+}
+
+func TestSonglinkClientSendsAPIKey(t *testing.T) {
+	client := newTestSonglinkClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	})
+	client.APIKey = "secret"
+
+	if _, err := client.Lookup(context.Background(), "https://music.apple.com/us/song/1"); err != nil {
+		t.Errorf("Lookup returned an unexpected error: %v", err)
+	}
+}
+
+func TestTokenBucketWaitHonoursContext(t *testing.T) {
+	bucket := newTokenBucket(1, time.Hour)
+	if err := bucket.Wait(context.Background()); err != nil {
+		t.Fatalf("first Wait returned an unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := bucket.Wait(ctx); err == nil {
+		t.Errorf("Wait on an empty bucket should return the context error")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d, ok := parseRetryAfter("7"); !ok || d != 7*time.Second {
+		t.Errorf("parseRetryAfter(7) = %v, %v; want 7s", d, ok)
+	}
+	if d, ok := parseRetryAfter("3600"); !ok || d != maxRetryAfter {
+		t.Errorf("parseRetryAfter(3600) = %v, %v; want %v", d, ok, maxRetryAfter)
+	}
+	if _, ok := parseRetryAfter("soon"); ok {
+		t.Errorf("parseRetryAfter(soon) should fail")
+	}
+}
+
+func TestSonglinkClientLookupOptions(t *testing.T) {
+	client := newTestSonglinkClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("userCountry") != "DE" || query.Get("songIfSingle") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	})
+	client.Options = LookupOptions{UserCountry: "DE", SongIfSingle: true}
+
+	if _, err := client.Lookup(context.Background(), "https://music.apple.com/de/album/1"); err != nil {
+		t.Errorf("Lookup returned an unexpected error: %v", err)
+	}
+}
+
+func TestLookupOptionsFromFlags(t *testing.T) {
+	original := *countryFlag
+	defer func() { *countryFlag = original }()
+
+	*countryFlag = ""
+	opts, err := lookupOptionsFromFlags(&Config{Country: "fi", SongIfSingle: true})
+	if err != nil || opts.UserCountry != "FI" || !opts.SongIfSingle {
+		t.Errorf("lookupOptionsFromFlags() = %+v, %v; want config defaults", opts, err)
+	}
+
+	*countryFlag = "jp"
+	opts, err = lookupOptionsFromFlags(&Config{Country: "fi"})
+	if err != nil || opts.UserCountry != "JP" {
+		t.Errorf("lookupOptionsFromFlags() = %+v, %v; want the flag to override config", opts, err)
+	}
+
+	*countryFlag = "usa"
+	if _, err := lookupOptionsFromFlags(nil); err == nil {
+		t.Errorf("lookupOptionsFromFlags() should reject %q", *countryFlag)
+	}
+}
@@ -1,81 +1,293 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
 	"net/url"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/atotto/clipboard"
 )
 
 type SonglinkResponse struct {
-	PageURL         string          `json:"pageUrl"`
-	LinksByPlatform LinksByPlatform `json:"linksByPlatform"`
+	EntityUniqueID     string                    `json:"entityUniqueId"`
+	UserCountry        string                    `json:"userCountry"`
+	PageURL            string                    `json:"pageUrl"`
+	LinksByPlatform    LinksByPlatform           `json:"linksByPlatform"`
+	EntitiesByUniqueID map[string]SonglinkEntity `json:"entitiesByUniqueId"`
 }
 
-type LinksByPlatform struct {
-	Spotify PlatformMusic `json:"spotify"`
-}
+// LinksByPlatform maps a song.link platform key (e.g. "spotify",
+// "youtubeMusic") to the link for that platform.
+type LinksByPlatform map[string]PlatformMusic
 
 type PlatformMusic struct {
-	URL string `json:"url"`
+	URL                 string `json:"url"`
+	NativeAppURIMobile  string `json:"nativeAppUriMobile,omitempty"`
+	NativeAppURIDesktop string `json:"nativeAppUriDesktop,omitempty"`
+	EntityUniqueID      string `json:"entityUniqueId"`
 }
 
-func GetLinks(searchURL string) error {
-	response, err := makeRequest(searchURL)
-	if err != nil {
-		return err
+type SonglinkEntity struct {
+	ID              string   `json:"id"`
+	Type            string   `json:"type"`
+	Title           string   `json:"title,omitempty"`
+	ArtistName      string   `json:"artistName,omitempty"`
+	ThumbnailURL    string   `json:"thumbnailUrl,omitempty"`
+	ThumbnailWidth  int      `json:"thumbnailWidth,omitempty"`
+	ThumbnailHeight int      `json:"thumbnailHeight,omitempty"`
+	APIProvider     string   `json:"apiProvider"`
+	Platforms       []string `json:"platforms"`
+}
+
+// Entity returns the entity song.link resolved the input URL to.
+func (r *SonglinkResponse) Entity() (SonglinkEntity, bool) {
+	entity, ok := r.EntitiesByUniqueID[r.EntityUniqueID]
+	return entity, ok
+}
+
+// knownPlatforms lists the song.link platform keys in the order they are
+// presented to the user.
+var knownPlatforms = []string{
+	"spotify",
+	"appleMusic",
+	"itunes",
+	"youtube",
+	"youtubeMusic",
+	"tidal",
+	"deezer",
+	"amazonMusic",
+	"amazonStore",
+	"soundcloud",
+	"bandcamp",
+	"pandora",
+	"napster",
+	"yandex",
+	"audiomack",
+	"anghami",
+	"boomplay",
+	"audius",
+	"spinrilla",
+}
+
+var platformAliases = map[string]string{
+	"apple":         "appleMusic",
+	"apple-music":   "appleMusic",
+	"youtube-music": "youtubeMusic",
+	"ytmusic":       "youtubeMusic",
+	"yt":            "youtube",
+	"amazon":        "amazonMusic",
+	"amazon-music":  "amazonMusic",
+	"amazon-store":  "amazonStore",
+}
+
+// normalizePlatform maps user input such as "youtube-music" or "Tidal" to the
+// platform key used in the song.link response.
+func normalizePlatform(name string) (string, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := platformAliases[key]; ok {
+		return alias, nil
 	}
+	for _, p := range knownPlatforms {
+		if strings.ToLower(p) == key {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown platform %q (known platforms: %s)", name, strings.Join(knownPlatforms, ", "))
+}
 
-	var linksResponse SonglinkResponse
-	decoder := json.NewDecoder(response.Body)
-	err = decoder.Decode(&linksResponse)
-	if err != nil {
-		return fmt.Errorf("error decoding JSON response: %w", err)
+// Platforms returns the platform keys present in the response, known
+// platforms first in their canonical order followed by any others sorted.
+func (l LinksByPlatform) Platforms() []string {
+	var platforms []string
+	seen := make(map[string]bool)
+	for _, p := range knownPlatforms {
+		if _, ok := l[p]; ok {
+			platforms = append(platforms, p)
+			seen[p] = true
+		}
+	}
+	var rest []string
+	for p := range l {
+		if !seen[p] {
+			rest = append(rest, p)
+		}
 	}
+	sort.Strings(rest)
+	return append(platforms, rest...)
+}
 
-	nonLocalURL := strings.ReplaceAll(linksResponse.PageURL, "/fi", "")
-	spotifyURL := linksResponse.LinksByPlatform.Spotify.URL
+// platformList is a repeatable flag accepting one or more platforms, either
+// as separate flags or comma separated.
+type platformList []string
 
-	var outputString string
-	if *xFlag {
-		outputString = fmt.Sprintf("%s\n%s", nonLocalURL, spotifyURL)
-	} else if *dFlag {
-		outputString = fmt.Sprintf("<%s>\n%s", nonLocalURL, spotifyURL)
-	} else if *sFlag {
-		outputString = spotifyURL
-	} else {
-		outputString = nonLocalURL
+func (p *platformList) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *platformList) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		platform, err := normalizePlatform(name)
+		if err != nil {
+			return err
+		}
+		*p = append(*p, platform)
+	}
+	return nil
+}
+
+// GetLinks resolves searchURL through song.link and formats the share text.
+// Outside of -json mode the text is also copied to the clipboard and printed.
+func GetLinks(ctx context.Context, searchURL string) (*LinksOutput, error) {
+	result, err := ResolveLink(ctx, searchURL)
+	if err != nil {
+		return nil, err
 	}
 
+	if *jsonFlag {
+		return result, nil
+	}
+
+	outputString := result.Output
 	err = clipboard.WriteAll(outputString)
 	if err != nil {
-		return fmt.Errorf("error copying output string to clipboard: %w", err)
+		return nil, fmt.Errorf("error copying output string to clipboard: %w", err)
 	}
 
-	fmt.Print(
+	fmt.Fprint(ui(),
 		"\nSuccess ✅\n",
 		outputString,
 		"\nCopied to the clipboard\n\n",
 	)
 
-	return nil
+	return result, nil
 }
 
-func makeRequest(searchURL string) (*http.Response, error) {
-	url := buildURL(searchURL)
-	response, err := http.Get(url)
+// ResolveLink resolves searchURL through song.link and formats the share
+// text without touching the clipboard.
+func ResolveLink(ctx context.Context, searchURL string) (*LinksOutput, error) {
+	linksResponse, err := FetchLinks(ctx, searchURL)
 	if err != nil {
-		return nil, fmt.Errorf("error making HTTP request: %w", err)
+		return nil, err
+	}
+
+	outputString, err := FormatLinks(linksResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LinksOutput{
+		URL:       searchURL,
+		ShareData: NewShareData(linksResponse),
+		Output:    outputString,
+	}, nil
+}
+
+// FetchLinks looks up searchURL through song.link. Cancelling ctx also ends
+// waits for the rate limiter and for retries.
+func FetchLinks(ctx context.Context, searchURL string) (*SonglinkResponse, error) {
+	client, err := defaultSonglinkClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.Lookup(ctx, searchURL)
+}
+
+// FormatLinks builds the share text for a song.link response according to
+// the -template, -x, -d, -s and -platform flags.
+func FormatLinks(linksResponse *SonglinkResponse) (string, error) {
+	if *templateFlag != "" {
+		text, err := resolveTemplate(*templateFlag)
+		if err != nil {
+			return "", err
+		}
+		return RenderTemplate(text, NewShareData(linksResponse))
+	}
+
+	nonLocalURL := shareablePageURL(linksResponse.PageURL)
+
+	platforms := selectedPlatforms()
+	if (*xFlag || *dFlag) && len(platforms) == 0 {
+		platforms = []string{"spotify"}
+	}
+
+	var lines []string
+	if *xFlag {
+		lines = append(lines, nonLocalURL)
+	} else if *dFlag {
+		lines = append(lines, fmt.Sprintf("<%s>", nonLocalURL))
+	} else if len(platforms) == 0 {
+		return nonLocalURL, nil
+	}
+
+	var missing []string
+	for _, platform := range platforms {
+		link, ok := linksResponse.LinksByPlatform[platform]
+		if !ok || link.URL == "" {
+			missing = append(missing, platform)
+			continue
+		}
+		lines = append(lines, link.URL)
+	}
+
+	if len(lines) == 0 {
+		return "", fmt.Errorf("no links available for platform(s): %s", strings.Join(missing, ", "))
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// selectedPlatforms returns the platforms requested with -platform, with -s
+// treated as a shorthand for -platform=spotify.
+func selectedPlatforms() []string {
+	var platforms []string
+	seen := make(map[string]bool)
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			platforms = append(platforms, p)
+		}
+	}
+	if *sFlag {
+		add("spotify")
+	}
+	for _, p := range platformFlag {
+		add(p)
+	}
+	return platforms
+}
+
+var songlinkHosts = map[string]bool{
+	"song.link":     true,
+	"album.link":    true,
+	"artist.link":   true,
+	"playlist.link": true,
+	"pods.link":     true,
+}
+
+var localePathSegment = regexp.MustCompile(`^[a-z]{2}$`)
+
+// shareablePageURL removes the country segment song.link adds to page URLs
+// (e.g. https://song.link/fi/i/123 becomes https://song.link/i/123) so the
+// link opens in the receiver's own region.
+func shareablePageURL(pageURL string) string {
+	u, err := url.Parse(pageURL)
+	if err != nil || !songlinkHosts[strings.ToLower(u.Host)] {
+		return pageURL
 	}
 
-	if response.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-OK HTTP response status: %s", response.Status)
+	segments := strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
+	if len(segments) < 2 || !localePathSegment.MatchString(segments[0]) {
+		return pageURL
 	}
 
-	return response, nil
+	u.Path = "/" + strings.Join(segments[1:], "/")
+	return u.String()
 }
 
 var songlinkAPIBase = "https://api.song.link"
@@ -1,13 +1,32 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 )
 
+func TestMain(m *testing.M) {
+	*noCacheFlag = true
+	os.Exit(m.Run())
+}
+
+func makeRequest(searchURL string) (*http.Response, error) {
+	client, err := defaultSonglinkClient()
+	if err != nil {
+		return nil, err
+	}
+	body, err := client.fetch(context.Background(), searchURL)
+	if err != nil {
+		return nil, err
+	}
+	return cachedResponse(body), nil
+}
+
 func TestMakeRequest(t *testing.T) {
 	searchURL := "https://music.apple.com/fi/album/caravan/1572919347?i=1572919354"
 
@@ -43,8 +62,8 @@ func TestMakeRequest(t *testing.T) {
 	}
 
    expectedSpotifyURL := "https://open.spotify.com/track/2Xtsv7BUMrNodQWH2JPOc0"
-   if linksResponse.LinksByPlatform.Spotify.URL != expectedSpotifyURL {
-       t.Errorf("makeRequest(%q) returned an unexpected Spotify URL: %s (want %s)", searchURL, linksResponse.LinksByPlatform.Spotify.URL, expectedSpotifyURL)
+   if linksResponse.LinksByPlatform["spotify"].URL != expectedSpotifyURL {
+       t.Errorf("makeRequest(%q) returned an unexpected Spotify URL: %s (want %s)", searchURL, linksResponse.LinksByPlatform["spotify"].URL, expectedSpotifyURL)
    }
 }
 
@@ -56,3 +75,54 @@ func TestBuildURL(t *testing.T) {
 		t.Errorf("buildURL(%q) = %q; want %q", searchURL, actualURL, expectedURL)
 	}
 }
+
+func TestFormatLinksPlatforms(t *testing.T) {
+	response := &SonglinkResponse{
+		PageURL: "https://song.link/fi/i/1572919354",
+		LinksByPlatform: LinksByPlatform{
+			"spotify":      {URL: "https://open.spotify.com/track/2Xtsv7BUMrNodQWH2JPOc0"},
+			"tidal":        {URL: "https://listen.tidal.com/track/189838410"},
+			"youtubeMusic": {URL: "https://music.youtube.com/watch?v=abc"},
+		},
+	}
+
+	original := platformFlag
+	defer func() { platformFlag = original }()
+
+	platformFlag = nil
+	if err := platformFlag.Set("tidal,youtube-music"); err != nil {
+		t.Fatalf("platformFlag.Set returned an unexpected error: %v", err)
+	}
+
+	output, err := FormatLinks(response)
+	if err != nil {
+		t.Fatalf("FormatLinks returned an unexpected error: %v", err)
+	}
+	expected := "https://listen.tidal.com/track/189838410\nhttps://music.youtube.com/watch?v=abc"
+	if output != expected {
+		t.Errorf("FormatLinks() = %q; want %q", output, expected)
+	}
+
+	platformFlag = platformList{"deezer"}
+	if _, err := FormatLinks(response); err == nil {
+		t.Errorf("FormatLinks() with a missing platform should return an error")
+	}
+}
+
+func TestShareablePageURL(t *testing.T) {
+	tests := map[string]string{
+		"https://song.link/fi/i/1572919354":            "https://song.link/i/1572919354",
+		"https://song.link/us/i/1572919354":            "https://song.link/i/1572919354",
+		"https://album.link/gb/i/1572919347":           "https://album.link/i/1572919347",
+		"https://song.link/i/1572919354":               "https://song.link/i/1572919354",
+		"https://song.link/s/2Xtsv7BUMrNodQWH2JPOc0":   "https://song.link/s/2Xtsv7BUMrNodQWH2JPOc0",
+		"https://song.link/fi":                         "https://song.link/fi",
+		"https://example.com/fi/i/1572919354":          "https://example.com/fi/i/1572919354",
+		"https://song.link/y/fi_abcdef":                "https://song.link/y/fi_abcdef",
+	}
+	for input, expected := range tests {
+		if got := shareablePageURL(input); got != expected {
+			t.Errorf("shareablePageURL(%q) = %q; want %q", input, got, expected)
+		}
+	}
+}
@@ -0,0 +1,515 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// AudioRequest asks an AudioSource for one track's audio.
+type AudioRequest struct {
+	Track SearchResult
+	// Format is the audio format to produce: "mp3" or "m4a".
+	Format string
+	// OutPath is where to save the audio, without the extension.
+	OutPath    string
+	Overwrite  bool
+	Debug      bool
+	OnProgress ProgressFunc
+}
+
+// Path is the file the source must create.
+func (r AudioRequest) Path() string {
+	return r.OutPath + "." + r.Format
+}
+
+// AudioSource finds a track's audio somewhere and saves it to req.Path().
+type AudioSource interface {
+	Name() string
+	Fetch(ctx context.Context, req AudioRequest) (string, error)
+}
+
+// Downloader fetches audio from its sources in order, falling back to the
+// next source when one fails.
+type Downloader struct {
+	sources []AudioSource
+}
+
+func NewDownloader(sources ...AudioSource) *Downloader {
+	return &Downloader{sources: sources}
+}
+
+func (d *Downloader) Fetch(ctx context.Context, req AudioRequest) (string, error) {
+	var errs []error
+	for _, source := range d.sources {
+		if req.Debug {
+			fmt.Fprintf(ui(), "Trying source: %s\n", source.Name())
+		}
+		path, err := source.Fetch(ctx, req)
+		if err == nil {
+			return path, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", source.Name(), err))
+	}
+	if len(errs) == 1 {
+		return "", errors.Unwrap(errs[0])
+	}
+	return "", errors.Join(errs...)
+}
+
+// SourcesConfig is the "sources" section of config.json.
+type SourcesConfig struct {
+	// Order lists the sources to try, e.g. ["youtube", "soundcloud"].
+	Order []string `json:"order,omitempty"`
+	// LocalDir is searched by the local source.
+	LocalDir string `json:"local_dir,omitempty"`
+	// Command is run by the command source, e.g.
+	// "my-fetch --isrc {isrc} -o {output}".
+	Command string `json:"command,omitempty"`
+	// DurationToleranceSeconds is how far a search result's length may be
+	// from the Apple Music track's (default 15).
+	DurationToleranceSeconds int `json:"duration_tolerance_seconds,omitempty"`
+}
+
+func (c SourcesConfig) durationTolerance() time.Duration {
+	if c.DurationToleranceSeconds > 0 {
+		return time.Duration(c.DurationToleranceSeconds) * time.Second
+	}
+	return defaultDurationTolerance
+}
+
+const defaultSources = "youtube"
+
+var sourceFlag = flag.String("source", "", "Where to get audio: youtube, soundcloud, bandcamp, local or command; comma separated to fall back in order")
+
+// audioDownloader builds the Downloader selected by -source, or by the
+// sources order in config.json.
+func audioDownloader() (*Downloader, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		config = &Config{}
+	}
+
+	names := *sourceFlag
+	if names == "" {
+		names = strings.Join(config.Sources.Order, ",")
+	}
+	if names == "" {
+		names = defaultSources
+	}
+
+	var sources []AudioSource
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		source, err := newAudioSource(name, config.Sources)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no audio sources selected")
+	}
+	return NewDownloader(sources...), nil
+}
+
+func newAudioSource(name string, config SourcesConfig) (AudioSource, error) {
+	switch name {
+	case "youtube":
+		return &ytdlpSearchSource{name: name, prefix: "ytsearch", flat: true, suffixes: []string{"", " topic", " lyrics"}, tolerance: config.durationTolerance()}, nil
+	case "soundcloud":
+		return &ytdlpSearchSource{name: name, prefix: "scsearch", suffixes: []string{""}, tolerance: config.durationTolerance()}, nil
+	case "bandcamp":
+		return &bandcampSource{tolerance: config.durationTolerance()}, nil
+	case "local":
+		if config.LocalDir == "" {
+			return nil, fmt.Errorf("the local source needs sources.local_dir in config.json")
+		}
+		return &localSource{dir: expandHome(config.LocalDir)}, nil
+	case "command":
+		if config.Command == "" {
+			return nil, fmt.Errorf("the command source needs sources.command in config.json")
+		}
+		return &commandSource{command: config.Command}, nil
+	default:
+		return nil, fmt.Errorf("unknown audio source %q (use youtube, soundcloud, bandcamp, local or command)", name)
+	}
+}
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// ytdlpSearchSource searches a site through yt-dlp, e.g. with "ytsearch",
+// and downloads the candidate that best matches the track. The track name
+// is searched with each suffix in turn until a candidate within tolerance of
+// the Apple Music duration downloads.
+type ytdlpSearchSource struct {
+	name      string
+	prefix    string
+	flat      bool
+	suffixes  []string
+	tolerance time.Duration
+}
+
+func (s *ytdlpSearchSource) Name() string { return s.name }
+
+func (s *ytdlpSearchSource) Fetch(ctx context.Context, req AudioRequest) (string, error) {
+	if err := lookYtDlp(req.Debug); err != nil {
+		return "", err
+	}
+
+	tried := make(map[string]bool)
+	var lastErr error
+	for _, suffix := range s.suffixes {
+		query := req.Track.Name + " " + req.Track.ArtistName + suffix
+		candidates, err := searchCandidates(ctx, s.prefix, query, s.flat, req.Debug)
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		ranked := rankCandidates(req.Track, candidates, s.tolerance)
+		if req.Debug {
+			fmt.Fprintf(ui(), "%d of %d results for %q match:\n", len(ranked), len(candidates), query)
+			for _, c := range ranked {
+				fmt.Fprintf(ui(), "  %5.1f  %s [%s, %s]\n", c.Score, c.Title, c.Channel, time.Duration(c.Duration)*time.Second)
+			}
+		}
+		if len(ranked) == 0 {
+			lastErr = fmt.Errorf("no result for %q within %s of the Apple Music duration", query, s.tolerance)
+			continue
+		}
+
+		for _, c := range ranked {
+			if tried[c.ID] {
+				continue
+			}
+			tried[c.ID] = true
+			err = runYtDlp(ctx, c.Link(), req)
+			if err == nil {
+				return req.Path(), nil
+			}
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			lastErr = err
+			break
+		}
+	}
+	return "", lastErr
+}
+
+// bandcampSource searches Bandcamp's track search and downloads the result
+// that best matches the track's title and artist.
+type bandcampSource struct {
+	tolerance time.Duration
+}
+
+func (s *bandcampSource) Name() string { return "bandcamp" }
+
+// minBandcampScore is the rankCandidates score a Bandcamp result needs, e.g.
+// the whole title and half of the artist's words. Search results carry no
+// duration to rule out the wrong recording, so anything less is too likely
+// to be another song.
+const minBandcampScore = 40
+
+var (
+	bandcampResultPattern  = regexp.MustCompile(`class="itemurl">\s*<a href="(https://[^"?]+/track/[^"?]+)`)
+	bandcampHeadingPattern = regexp.MustCompile(`class="heading">\s*<a[^>]*>([^<]*)</a>`)
+	bandcampSubheadPattern = regexp.MustCompile(`class="subhead">([^<]*)<`)
+)
+
+func (s *bandcampSource) Fetch(ctx context.Context, req AudioRequest) (string, error) {
+	searchURL := "https://bandcamp.com/search?item_type=t&q=" + url.QueryEscape(req.Track.ArtistName+" "+req.Track.Name)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, searchURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("search failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("search failed: %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("search failed: %w", err)
+	}
+
+	candidates := bandcampCandidates(string(body))
+	ranked := rankCandidates(req.Track, candidates, s.tolerance)
+	if req.Debug {
+		fmt.Fprintf(ui(), "%d Bandcamp results for %s - %s:\n", len(ranked), req.Track.ArtistName, req.Track.Name)
+		for _, c := range ranked {
+			fmt.Fprintf(ui(), "  %5.1f  %s [%s]\n", c.Score, c.Title, c.Uploader)
+		}
+	}
+	if len(ranked) == 0 || ranked[0].Score < minBandcampScore {
+		return "", fmt.Errorf("no match for %s - %s", req.Track.ArtistName, req.Track.Name)
+	}
+	if err := runYtDlp(ctx, ranked[0].Link(), req); err != nil {
+		return "", err
+	}
+	return req.Path(), nil
+}
+
+// bandcampCandidates reads the track results of a Bandcamp search page. The
+// artist comes from the "from <album> by <artist>" line under each title.
+func bandcampCandidates(page string) []Candidate {
+	var candidates []Candidate
+	for _, result := range strings.Split(page, `class="searchresult`)[1:] {
+		link := bandcampResultPattern.FindStringSubmatch(result)
+		heading := bandcampHeadingPattern.FindStringSubmatch(result)
+		if link == nil || heading == nil {
+			continue
+		}
+		var artist string
+		if subhead := bandcampSubheadPattern.FindStringSubmatch(result); subhead != nil {
+			text := strings.Join(strings.Fields(html.UnescapeString(subhead[1])), " ")
+			if i := strings.LastIndex(" "+text, " by "); i >= 0 {
+				artist = text[i+3:]
+			}
+		}
+		candidates = append(candidates, Candidate{
+			ID:         link[1],
+			Title:      strings.TrimSpace(html.UnescapeString(heading[1])),
+			Uploader:   artist,
+			WebpageURL: link[1],
+		})
+	}
+	return candidates
+}
+
+func lookYtDlp(debug bool) error {
+	ytdlpPath, err := exec.LookPath("yt-dlp")
+	if err != nil {
+		return fmt.Errorf("yt-dlp not found in PATH. Please install it: brew install yt-dlp (macOS) or see README for other systems")
+	}
+	return checkYtDlpVersion(ytdlpPath, debug)
+}
+
+// runYtDlp downloads target, a URL or search, as req.Format audio.
+func runYtDlp(ctx context.Context, target string, req AudioRequest) error {
+	if err := lookYtDlp(req.Debug); err != nil {
+		return err
+	}
+
+	args := []string{
+		target,
+		"--extract-audio",
+		"--audio-format", req.Format,
+		"--audio-quality", "192K",
+		"--output", req.OutPath + ".%(ext)s",
+		"--no-check-certificates",
+		"--no-playlist",
+		"--no-warnings",
+		"--ignore-errors",
+	}
+	if req.Overwrite {
+		args = append(args, "--force-overwrites")
+	}
+	args = append(args, ytdlpProgressArgs...)
+
+	cmd := commandContext(ctx, "yt-dlp", args...)
+	if req.Debug {
+		fmt.Fprintf(ui(), "Downloading: %s\n", target)
+		cmd.Stdout = newProgressWriter(ui(), req.OnProgress, true)
+		cmd.Stderr = ui()
+	} else {
+		cmd.Stdout = newProgressWriter(io.Discard, req.OnProgress, false)
+		cmd.Stderr = io.Discard
+	}
+	runErr := cmd.Run()
+	if _, err := os.Stat(req.Path()); err == nil {
+		return nil
+	}
+	if runErr == nil {
+		return fmt.Errorf("audio file was not created - download may have failed")
+	}
+	if strings.Contains(runErr.Error(), "Requested format is not available") ||
+		strings.Contains(runErr.Error(), "Signature extraction failed") {
+		return fmt.Errorf("download failed - yt-dlp is likely outdated. Please update: yt-dlp -U or brew upgrade yt-dlp")
+	}
+	return fmt.Errorf("download failed (try --debug for details): %w", runErr)
+}
+
+// localSource takes tracks from a directory of audio files named after the
+// artist and title, converting them with ffmpeg when the format differs.
+type localSource struct {
+	dir string
+}
+
+func (s *localSource) Name() string { return "local" }
+
+var localAudioExtensions = map[string]bool{
+	".mp3": true, ".m4a": true, ".aac": true, ".flac": true, ".wav": true,
+	".aiff": true, ".aif": true, ".ogg": true, ".opus": true, ".alac": true,
+}
+
+func (s *localSource) Fetch(ctx context.Context, req AudioRequest) (string, error) {
+	src, err := s.find(req.Track)
+	if err != nil {
+		return "", err
+	}
+	if req.Debug {
+		fmt.Fprintf(ui(), "Using local file: %s\n", src)
+	}
+
+	dst := req.Path()
+	if req.Overwrite {
+		os.Remove(dst)
+	}
+	if strings.EqualFold(filepath.Ext(src), "."+req.Format) {
+		if err := reuseFile(src, dst); err != nil {
+			return "", err
+		}
+		return dst, nil
+	}
+	if err := convertAudio(ctx, src, dst, req); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
+
+// find returns the audio file whose name best matches the track: one named
+// "<artist> - <title>", else one containing both.
+func (s *localSource) find(track SearchResult) (string, error) {
+	title := normalizeName(track.Name)
+	artist := normalizeName(track.ArtistName)
+	exact := artist + title
+
+	var best string
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !localAudioExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		name := normalizeName(strings.TrimSuffix(d.Name(), filepath.Ext(path)))
+		if name == exact {
+			best = path
+			return fs.SkipAll
+		}
+		if best == "" && strings.Contains(name, title) && strings.Contains(name, artist) {
+			best = path
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to search %s: %w", s.dir, err)
+	}
+	if best == "" {
+		return "", fmt.Errorf("no file for %s - %s in %s", track.ArtistName, track.Name, s.dir)
+	}
+	return best, nil
+}
+
+// normalizeName lowercases s and drops everything but letters and digits,
+// so that "AC/DC - T.N.T." and "acdc tnt" compare equal.
+func normalizeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+func convertAudio(ctx context.Context, src, dst string, req AudioRequest) error {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("ffmpeg not found in PATH. Please install it: brew install ffmpeg (macOS) or see README")
+	}
+	codec := "libmp3lame"
+	if req.Format == "m4a" {
+		codec = "aac"
+	}
+	ff := commandContext(ctx, "ffmpeg", "-n", "-i", src, "-vn", "-c:a", codec, "-b:a", "192k", dst)
+	if req.Debug {
+		ff.Stdout = ui()
+		ff.Stderr = ui()
+	}
+	if err := ff.Run(); err != nil {
+		return fmt.Errorf("converting %s failed: %w", src, err)
+	}
+	return nil
+}
+
+// commandSource runs a user-supplied command that saves the track's audio to
+// {output}. The command is split on spaces and not run through a shell;
+// placeholders are replaced within each argument.
+type commandSource struct {
+	command string
+}
+
+func (s *commandSource) Name() string { return "command" }
+
+func (s *commandSource) Fetch(ctx context.Context, req AudioRequest) (string, error) {
+	args := expandSourceCommand(s.command, req)
+	if len(args) == 0 {
+		return "", fmt.Errorf("sources.command is empty")
+	}
+	if req.Overwrite {
+		os.Remove(req.Path())
+	}
+
+	cmd := commandContext(ctx, args[0], args[1:]...)
+	if req.Debug {
+		fmt.Fprintf(ui(), "Running: %s\n", strings.Join(args, " "))
+		cmd.Stdout = ui()
+		cmd.Stderr = ui()
+	}
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed: %w", args[0], err)
+	}
+	if _, err := os.Stat(req.Path()); err != nil {
+		return "", fmt.Errorf("%s did not create %s", args[0], req.Path())
+	}
+	return req.Path(), nil
+}
+
+func expandSourceCommand(command string, req AudioRequest) []string {
+	replacer := strings.NewReplacer(
+		"{artist}", req.Track.ArtistName,
+		"{title}", req.Track.Name,
+		"{isrc}", req.Track.ISRC,
+		"{id}", req.Track.ID,
+		"{format}", req.Format,
+		"{output}", req.Path(),
+	)
+	args := strings.Fields(command)
+	for i, arg := range args {
+		args[i] = replacer.Replace(arg)
+	}
+	return args
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeSource struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (s *fakeSource) Name() string { return s.name }
+
+func (s *fakeSource) Fetch(ctx context.Context, req AudioRequest) (string, error) {
+	s.calls++
+	if s.err != nil {
+		return "", s.err
+	}
+	return req.Path(), nil
+}
+
+func TestDownloaderFallsBack(t *testing.T) {
+	failing := &fakeSource{name: "youtube", err: errors.New("no results")}
+	working := &fakeSource{name: "soundcloud"}
+	unused := &fakeSource{name: "bandcamp"}
+
+	path, err := NewDownloader(failing, working, unused).Fetch(context.Background(), AudioRequest{Format: "mp3", OutPath: "out/song"})
+	if err != nil || path != "out/song.mp3" {
+		t.Fatalf("Fetch() = %q, %v; want out/song.mp3", path, err)
+	}
+	if failing.calls != 1 || working.calls != 1 || unused.calls != 0 {
+		t.Errorf("calls = %d, %d, %d; want 1, 1, 0", failing.calls, working.calls, unused.calls)
+	}
+
+	_, err = NewDownloader(failing, &fakeSource{name: "bandcamp", err: errors.New("no match")}).Fetch(context.Background(), AudioRequest{})
+	if err == nil || !strings.Contains(err.Error(), "youtube: no results") || !strings.Contains(err.Error(), "bandcamp: no match") {
+		t.Errorf("Fetch() error = %v; want both sources' errors", err)
+	}
+}
+
+func TestLocalSourceFind(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"AC_DC - T.N.T. (Live).flac",
+		"Albums/AC_DC - T.N.T..mp3",
+		"AC_DC - T.N.T..txt",
+		"AC_DC - Thunderstruck.mp3",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("audio"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source := &localSource{dir: dir}
+	got, err := source.find(SearchResult{Name: "T.N.T.", ArtistName: "AC/DC"})
+	if err != nil {
+		t.Fatalf("find returned an unexpected error: %v", err)
+	}
+	if want := filepath.Join(dir, "Albums", "AC_DC - T.N.T..mp3"); got != want {
+		t.Errorf("find() = %q; want the exact match %q", got, want)
+	}
+
+	if _, err := source.find(SearchResult{Name: "Back in Black", ArtistName: "AC/DC"}); err == nil {
+		t.Error("find() matched a track that isn't there")
+	}
+
+	out := filepath.Join(t.TempDir(), "AC_DC - T.N.T.")
+	path, err := source.Fetch(context.Background(), AudioRequest{Track: SearchResult{Name: "T.N.T.", ArtistName: "AC/DC"}, Format: "mp3", OutPath: out})
+	if err != nil || path != out+".mp3" {
+		t.Fatalf("Fetch() = %q, %v; want %s.mp3", path, err, out)
+	}
+}
+
+func TestExpandSourceCommand(t *testing.T) {
+	req := AudioRequest{
+		Track:   SearchResult{ID: "1441164430", Name: "Here Comes the Sun", ArtistName: "The Beatles", ISRC: "GBAYE0601690"},
+		Format:  "mp3",
+		OutPath: "downloads/The Beatles - Here Comes the Sun",
+	}
+	got := expandSourceCommand("fetch --isrc {isrc} --query {artist}/{title} -o {output}", req)
+	want := []string{"fetch", "--isrc", "GBAYE0601690", "--query", "The Beatles/Here Comes the Sun", "-o", "downloads/The Beatles - Here Comes the Sun.mp3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expandSourceCommand() = %q; want %q", got, want)
+	}
+}
+
+func TestNewAudioSource(t *testing.T) {
+	if _, err := newAudioSource("spotify", SourcesConfig{}); err == nil {
+		t.Error("newAudioSource accepted an unknown source")
+	}
+	if _, err := newAudioSource("local", SourcesConfig{}); err == nil {
+		t.Error("newAudioSource accepted the local source without a directory")
+	}
+	if source, err := newAudioSource("soundcloud", SourcesConfig{}); err != nil || source.Name() != "soundcloud" {
+		t.Errorf("newAudioSource(soundcloud) = %v, %v", source, err)
+	}
+}
+
+func TestBandcampCandidates(t *testing.T) {
+	page := `<ul class="result-items">
+<li class="searchresult data-search">
+  <div class="heading">
+    <a href="https://fan.bandcamp.com/track/tribute?from=search">Tribute (Tenacious D cover)</a>
+  </div>
+  <div class="subhead">
+    from Covers
+    by Some Fan
+  </div>
+  <div class="itemurl">
+    <a href="https://fan.bandcamp.com/track/tribute?from=search">https://fan.bandcamp.com/track/tribute</a>
+  </div>
+</li>
+<li class="searchresult data-search">
+  <div class="heading">
+    <a href="https://tenaciousd.bandcamp.com/track/tribute?from=search">
+      Tribute
+    </a>
+  </div>
+  <div class="subhead">
+    from Tenacious D
+    by Tenacious D
+  </div>
+  <div class="itemurl">
+    <a href="https://tenaciousd.bandcamp.com/track/tribute?from=search">https://tenaciousd.bandcamp.com/track/tribute</a>
+  </div>
+</li>
+</ul>`
+
+	candidates := bandcampCandidates(page)
+	if len(candidates) != 2 {
+		t.Fatalf("bandcampCandidates returned %d results; want 2", len(candidates))
+	}
+	if c := candidates[1]; c.Title != "Tribute" || c.Uploader != "Tenacious D" || c.Link() != "https://tenaciousd.bandcamp.com/track/tribute" {
+		t.Errorf("second result = %+v", c)
+	}
+
+	ranked := rankCandidates(SearchResult{Name: "Tribute", ArtistName: "Tenacious D"}, candidates, defaultDurationTolerance)
+	if len(ranked) == 0 || ranked[0].Uploader != "Tenacious D" || ranked[0].Score < minBandcampScore {
+		t.Errorf("best match = %+v; want the Tenacious D upload scoring at least %d", ranked, minBandcampScore)
+	}
+	ranked = rankCandidates(SearchResult{Name: "Master Exploder", ArtistName: "Tenacious D"}, candidates, defaultDurationTolerance)
+	if len(ranked) > 0 && ranked[0].Score >= minBandcampScore {
+		t.Errorf("%+v scores %.1f for another song; want below %d", ranked[0].Candidate, ranked[0].Score, minBandcampScore)
+	}
+}
@@ -0,0 +1,544 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxArtworkSize caps the artwork embedded in tags.
+const maxArtworkSize = 20 << 20
+
+// TrackTags are the catalog fields written into downloaded files.
+type TrackTags struct {
+	Title       string
+	Artist      string
+	Album       string
+	AlbumArtist string
+	TrackNumber int
+	TrackCount  int
+	DiscNumber  int
+	// Date is the release date, either "2006-01-02" or just the year.
+	Date        string
+	Genre       string
+	ISRC        string
+	Composer    string
+	Artwork     []byte
+	ArtworkMIME string
+}
+
+// NewTrackTags returns the tags for track, without artwork.
+func NewTrackTags(track SearchResult) TrackTags {
+	return TrackTags{
+		Title:       track.Name,
+		Artist:      track.ArtistName,
+		Album:       track.AlbumName,
+		AlbumArtist: track.AlbumArtist,
+		TrackNumber: track.TrackNumber,
+		TrackCount:  track.TrackCount,
+		DiscNumber:  track.DiscNumber,
+		Date:        track.ReleaseDate,
+		Genre:       track.Genre,
+		ISRC:        track.ISRC,
+		Composer:    track.Composer,
+	}
+}
+
+// tagFile writes track's catalog data and full-resolution artwork into the
+// file at path. When the artwork can't be fetched the other tags are still
+// written and the error is returned.
+func tagFile(ctx context.Context, path string, track SearchResult) error {
+	tags := NewTrackTags(track)
+
+	var artErr error
+	artURL := track.FullArtworkURL
+	if artURL == "" {
+		artURL = track.ArtworkURL
+	}
+	if artURL != "" {
+		tags.Artwork, artErr = fetchArtwork(ctx, artURL)
+		if artErr == nil {
+			tags.ArtworkMIME = http.DetectContentType(tags.Artwork)
+		} else {
+			artErr = fmt.Errorf("failed to download artwork: %w", artErr)
+		}
+	}
+
+	return errors.Join(WriteTags(path, tags), artErr)
+}
+
+func fetchArtwork(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad status downloading %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxArtworkSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxArtworkSize {
+		return nil, fmt.Errorf("artwork larger than %d bytes", maxArtworkSize)
+	}
+	return data, nil
+}
+
+// WriteTags replaces the tags of an MP3 (ID3v2.4) or MP4/M4A (ilst atoms)
+// file with tags.
+func WriteTags(path string, tags TrackTags) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp3":
+		return writeID3v24(path, tags)
+	case ".mp4", ".m4a":
+		return writeMP4Tags(path, tags)
+	default:
+		return fmt.Errorf("can't tag %s files", filepath.Ext(path))
+	}
+}
+
+// rewriteFile replaces path with what write copies from it, going through a
+// temporary file so a failure leaves the original intact.
+func rewriteFile(path string, write func(dst io.Writer, src *os.File) error) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".songlink-tags-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp, src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	src.Close()
+	return os.Rename(tmp.Name(), path)
+}
+
+// ID3v2.4
+
+const id3Padding = 1024
+
+func writeID3v24(path string, tags TrackTags) error {
+	tag, err := id3v24Tag(tags)
+	if err != nil {
+		return err
+	}
+	return rewriteFile(path, func(dst io.Writer, src *os.File) error {
+		skip, err := id3v2Length(src)
+		if err != nil {
+			return err
+		}
+		if _, err := src.Seek(skip, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := dst.Write(tag); err != nil {
+			return err
+		}
+		_, err = io.Copy(dst, src)
+		return err
+	})
+}
+
+// id3v2Length returns the length of the ID3v2 tag at the start of r, or 0
+// when there is none.
+func id3v2Length(r io.ReaderAt) (int64, error) {
+	header := make([]byte, 10)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if string(header[:3]) != "ID3" {
+		return 0, nil
+	}
+	length := int64(decodeSyncsafe(header[6:10])) + 10
+	if header[5]&0x10 != 0 {
+		length += 10 // footer
+	}
+	return length, nil
+}
+
+func id3v24Tag(tags TrackTags) ([]byte, error) {
+	var frames bytes.Buffer
+	text := func(id, value string) {
+		if value != "" {
+			writeID3Frame(&frames, id, append([]byte{3}, value...))
+		}
+	}
+	number := func(n, total int) string {
+		switch {
+		case n <= 0:
+			return ""
+		case total > 0:
+			return fmt.Sprintf("%d/%d", n, total)
+		default:
+			return strconv.Itoa(n)
+		}
+	}
+
+	text("TIT2", tags.Title)
+	text("TPE1", tags.Artist)
+	text("TALB", tags.Album)
+	text("TPE2", tags.AlbumArtist)
+	text("TRCK", number(tags.TrackNumber, tags.TrackCount))
+	text("TPOS", number(tags.DiscNumber, 0))
+	text("TDRC", tags.Date)
+	text("TCON", tags.Genre)
+	text("TSRC", tags.ISRC)
+	text("TCOM", tags.Composer)
+	if len(tags.Artwork) > 0 {
+		// UTF-8, MIME type, front cover, empty description, picture data.
+		var apic bytes.Buffer
+		apic.WriteByte(3)
+		apic.WriteString(tags.ArtworkMIME)
+		apic.WriteByte(0)
+		apic.WriteByte(3)
+		apic.WriteByte(0)
+		apic.Write(tags.Artwork)
+		writeID3Frame(&frames, "APIC", apic.Bytes())
+	}
+
+	size := frames.Len() + id3Padding
+	if size >= 1<<28 {
+		return nil, fmt.Errorf("ID3 tag too large (%d bytes)", size)
+	}
+	tag := make([]byte, 0, 10+size)
+	tag = append(tag, 'I', 'D', '3', 4, 0, 0)
+	tag = append(tag, encodeSyncsafe(uint32(size))...)
+	tag = append(tag, frames.Bytes()...)
+	return append(tag, make([]byte, id3Padding)...), nil
+}
+
+func writeID3Frame(w *bytes.Buffer, id string, payload []byte) {
+	w.WriteString(id)
+	w.Write(encodeSyncsafe(uint32(len(payload))))
+	w.Write([]byte{0, 0})
+	w.Write(payload)
+}
+
+// encodeSyncsafe encodes n in four bytes of seven bits each, as ID3v2.4
+// sizes are.
+func encodeSyncsafe(n uint32) []byte {
+	return []byte{byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+}
+
+func decodeSyncsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7f)<<21 | uint32(b[1]&0x7f)<<14 | uint32(b[2]&0x7f)<<7 | uint32(b[3]&0x7f)
+}
+
+// MP4
+
+// atom is an MP4 box: data holds the whole box, header its size and type.
+type atom struct {
+	typ    string
+	data   []byte
+	header int
+}
+
+func (a atom) body() []byte {
+	return a.data[a.header:]
+}
+
+func parseAtoms(data []byte) ([]atom, error) {
+	var atoms []atom
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, errors.New("truncated MP4 atom")
+		}
+		typ := string(data[4:8])
+		size, header := uint64(binary.BigEndian.Uint32(data)), 8
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, errors.New("truncated MP4 atom")
+			}
+			size, header = binary.BigEndian.Uint64(data[8:]), 16
+		}
+		if size < uint64(header) || size > uint64(len(data)) {
+			return nil, fmt.Errorf("invalid size for MP4 atom %q", typ)
+		}
+		atoms = append(atoms, atom{typ: typ, data: data[:size], header: header})
+		data = data[size:]
+	}
+	return atoms, nil
+}
+
+func makeAtom(typ string, parts ...[]byte) []byte {
+	size := 8
+	for _, part := range parts {
+		size += len(part)
+	}
+	out := make([]byte, 8, size)
+	binary.BigEndian.PutUint32(out, uint32(size))
+	copy(out[4:], typ)
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+	return out
+}
+
+func uint32Bytes(n uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, n)
+}
+
+// topLevelAtom is the position of an atom directly in the file.
+type topLevelAtom struct {
+	typ          string
+	offset, size int64
+}
+
+func readTopLevelAtoms(r io.ReaderAt, fileSize int64) ([]topLevelAtom, error) {
+	var atoms []topLevelAtom
+	header := make([]byte, 16)
+	for pos := int64(0); pos < fileSize; {
+		if _, err := r.ReadAt(header[:8], pos); err != nil {
+			return nil, fmt.Errorf("truncated MP4 atom at %d", pos)
+		}
+		typ := string(header[4:8])
+		size, headerSize := int64(binary.BigEndian.Uint32(header)), int64(8)
+		switch size {
+		case 0:
+			size = fileSize - pos
+		case 1:
+			if _, err := r.ReadAt(header[8:16], pos+8); err != nil {
+				return nil, fmt.Errorf("truncated MP4 atom at %d", pos)
+			}
+			size, headerSize = int64(binary.BigEndian.Uint64(header[8:])), 16
+		}
+		if size < headerSize || pos+size > fileSize {
+			return nil, fmt.Errorf("invalid size for MP4 atom %q", typ)
+		}
+		atoms = append(atoms, topLevelAtom{typ, pos, size})
+		pos += size
+	}
+	return atoms, nil
+}
+
+// writeMP4Tags replaces moov/udta/meta with an iTunes-style ilst. When moov
+// sits before the media data and changes size, the chunk offsets in
+// stco/co64 are shifted to match.
+func writeMP4Tags(path string, tags TrackTags) error {
+	return rewriteFile(path, func(dst io.Writer, src *os.File) error {
+		info, err := src.Stat()
+		if err != nil {
+			return err
+		}
+		atoms, err := readTopLevelAtoms(src, info.Size())
+		if err != nil {
+			return err
+		}
+		var moov *topLevelAtom
+		for i := range atoms {
+			if atoms[i].typ == "moov" {
+				moov = &atoms[i]
+				break
+			}
+		}
+		if moov == nil {
+			return errors.New("no moov atom found")
+		}
+
+		oldMoov := make([]byte, moov.size)
+		if _, err := src.ReadAt(oldMoov, moov.offset); err != nil {
+			return err
+		}
+		newMoov, err := setMP4Metadata(oldMoov, mp4Ilst(tags))
+		if err != nil {
+			return err
+		}
+		moovEnd := moov.offset + moov.size
+		if delta := int64(len(newMoov)) - moov.size; delta != 0 && moovEnd < info.Size() {
+			if err := shiftChunkOffsets(newMoov, moovEnd, delta); err != nil {
+				return err
+			}
+		}
+
+		if _, err := io.Copy(dst, io.NewSectionReader(src, 0, moov.offset)); err != nil {
+			return err
+		}
+		if _, err := dst.Write(newMoov); err != nil {
+			return err
+		}
+		_, err = io.Copy(dst, io.NewSectionReader(src, moovEnd, info.Size()-moovEnd))
+		return err
+	})
+}
+
+// setMP4Metadata returns moov with its udta/meta replaced by one holding
+// ilst, keeping the other udta children.
+func setMP4Metadata(moov []byte, ilst []byte) ([]byte, error) {
+	root, err := parseAtoms(moov)
+	if err != nil {
+		return nil, err
+	}
+	if len(root) != 1 || root[0].typ != "moov" {
+		return nil, errors.New("not a moov atom")
+	}
+	children, err := parseAtoms(root[0].body())
+	if err != nil {
+		return nil, err
+	}
+
+	hdlr := makeAtom("hdlr", uint32Bytes(0), uint32Bytes(0), []byte("mdirappl"), make([]byte, 9))
+	meta := makeAtom("meta", uint32Bytes(0), hdlr, ilst)
+
+	var parts [][]byte
+	foundUdta := false
+	for _, child := range children {
+		if child.typ != "udta" || foundUdta {
+			parts = append(parts, child.data)
+			continue
+		}
+		foundUdta = true
+		udtaChildren, err := parseAtoms(child.body())
+		if err != nil {
+			return nil, err
+		}
+		var udta [][]byte
+		for _, c := range udtaChildren {
+			if c.typ != "meta" {
+				udta = append(udta, c.data)
+			}
+		}
+		parts = append(parts, makeAtom("udta", append(udta, meta)...))
+	}
+	if !foundUdta {
+		parts = append(parts, makeAtom("udta", meta))
+	}
+	return makeAtom("moov", parts...), nil
+}
+
+func mp4Ilst(tags TrackTags) []byte {
+	var items [][]byte
+	data := func(kind uint32, payload []byte) []byte {
+		return makeAtom("data", uint32Bytes(kind), uint32Bytes(0), payload)
+	}
+	text := func(typ, value string) {
+		if value != "" {
+			items = append(items, makeAtom(typ, data(1, []byte(value))))
+		}
+	}
+	number := func(typ string, n, total, size int) {
+		if n > 0 {
+			payload := make([]byte, size)
+			binary.BigEndian.PutUint16(payload[2:], uint16(n))
+			binary.BigEndian.PutUint16(payload[4:], uint16(total))
+			items = append(items, makeAtom(typ, data(0, payload)))
+		}
+	}
+
+	text("\xa9nam", tags.Title)
+	text("\xa9ART", tags.Artist)
+	text("\xa9alb", tags.Album)
+	text("aART", tags.AlbumArtist)
+	number("trkn", tags.TrackNumber, tags.TrackCount, 8)
+	number("disk", tags.DiscNumber, 0, 6)
+	text("\xa9day", tags.Date)
+	text("\xa9gen", tags.Genre)
+	text("\xa9wrt", tags.Composer)
+	if tags.ISRC != "" {
+		items = append(items, makeAtom("----",
+			makeAtom("mean", uint32Bytes(0), []byte("com.apple.iTunes")),
+			makeAtom("name", uint32Bytes(0), []byte("ISRC")),
+			data(1, []byte(tags.ISRC)),
+		))
+	}
+	if len(tags.Artwork) > 0 {
+		switch tags.ArtworkMIME {
+		case "image/jpeg":
+			items = append(items, makeAtom("covr", data(13, tags.Artwork)))
+		case "image/png":
+			items = append(items, makeAtom("covr", data(14, tags.Artwork)))
+		}
+	}
+	return makeAtom("ilst", items...)
+}
+
+// chunkOffsetContainers are the atoms on the way from moov to stco/co64.
+var chunkOffsetContainers = map[string]bool{
+	"moov": true, "trak": true, "mdia": true, "minf": true, "stbl": true,
+}
+
+// shiftChunkOffsets adds delta to every chunk offset in the atoms of data
+// that points at or past from.
+func shiftChunkOffsets(data []byte, from, delta int64) error {
+	atoms, err := parseAtoms(data)
+	if err != nil {
+		return err
+	}
+	for _, a := range atoms {
+		body := a.body()
+		switch {
+		case chunkOffsetContainers[a.typ]:
+			if err := shiftChunkOffsets(body, from, delta); err != nil {
+				return err
+			}
+		case a.typ == "stco" || a.typ == "co64":
+			width := 4
+			if a.typ == "co64" {
+				width = 8
+			}
+			if len(body) < 8 {
+				return fmt.Errorf("truncated %s atom", a.typ)
+			}
+			count := int(binary.BigEndian.Uint32(body[4:]))
+			entries := body[8:]
+			if len(entries) < count*width {
+				return fmt.Errorf("truncated %s atom", a.typ)
+			}
+			for i := 0; i < count; i++ {
+				entry := entries[i*width:]
+				if width == 4 {
+					offset := int64(binary.BigEndian.Uint32(entry))
+					if offset < from {
+						continue
+					}
+					if offset+delta > 1<<32-1 {
+						return errors.New("chunk offset overflows stco")
+					}
+					binary.BigEndian.PutUint32(entry, uint32(offset+delta))
+				} else {
+					offset := int64(binary.BigEndian.Uint64(entry))
+					if offset >= from {
+						binary.BigEndian.PutUint64(entry, uint64(offset+delta))
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testTags = TrackTags{
+	Title:       "Song",
+	Artist:      "Artist",
+	Album:       "Album",
+	AlbumArtist: "Album Artist",
+	TrackNumber: 3,
+	TrackCount:  12,
+	DiscNumber:  1,
+	Date:        "2020-05-01",
+	Genre:       "Rock",
+	ISRC:        "USABC2000001",
+	Composer:    "Composer",
+	Artwork:     []byte("\xff\xd8\xffjpeg"),
+	ArtworkMIME: "image/jpeg",
+}
+
+func TestWriteID3v24(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "song.mp3")
+	oldTag := append([]byte("ID3\x03\x00\x00"), encodeSyncsafe(4)...)
+	oldTag = append(oldTag, "junk"...)
+	if err := os.WriteFile(path, append(oldTag, "audio"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteTags(path, testTags); err != nil {
+		t.Fatalf("WriteTags: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data[:5]) != "ID3\x04\x00" {
+		t.Fatalf("header = %q; want an ID3v2.4 header", data[:5])
+	}
+	size := int(decodeSyncsafe(data[6:10]))
+	if got := string(data[10+size:]); got != "audio" {
+		t.Fatalf("audio after tag = %q; want %q", got, "audio")
+	}
+
+	frames := make(map[string][]byte)
+	for rest := data[10 : 10+size]; len(rest) >= 10 && rest[0] != 0; {
+		n := int(decodeSyncsafe(rest[4:8]))
+		frames[string(rest[:4])] = rest[10 : 10+n]
+		rest = rest[10+n:]
+	}
+	for id, want := range map[string]string{
+		"TIT2": "Song", "TPE1": "Artist", "TALB": "Album", "TPE2": "Album Artist",
+		"TRCK": "3/12", "TPOS": "1", "TDRC": "2020-05-01", "TCON": "Rock",
+		"TSRC": "USABC2000001", "TCOM": "Composer",
+	} {
+		if got := string(frames[id]); got != "\x03"+want {
+			t.Errorf("%s = %q; want %q", id, got, "\x03"+want)
+		}
+	}
+	if apic := frames["APIC"]; !bytes.HasSuffix(apic, testTags.Artwork) || !bytes.HasPrefix(apic, []byte("\x03image/jpeg\x00\x03\x00")) {
+		t.Errorf("APIC = %q", apic)
+	}
+}
+
+func TestWriteMP4Tags(t *testing.T) {
+	// moov comes before mdat, so the chunk offset has to move with the
+	// media data when moov grows.
+	ftyp := makeAtom("ftyp", []byte("isom\x00\x00\x02\x00"))
+	stco := func(offset uint32) []byte {
+		return makeAtom("stco", uint32Bytes(0), uint32Bytes(1), uint32Bytes(offset))
+	}
+	moov := func(offset uint32) []byte {
+		trak := makeAtom("trak", makeAtom("mdia", makeAtom("minf", makeAtom("stbl", stco(offset)))))
+		udta := makeAtom("udta", makeAtom("meta", uint32Bytes(0), makeAtom("ilst")), makeAtom("name", []byte("keep")))
+		return makeAtom("moov", makeAtom("mvhd", make([]byte, 4)), trak, udta)
+	}
+	mediaOffset := uint32(len(ftyp) + len(moov(0)) + 8)
+	file := append(append(append([]byte{}, ftyp...), moov(mediaOffset)...), makeAtom("mdat", []byte("media"))...)
+
+	path := filepath.Join(t.TempDir(), "song.mp4")
+	if err := os.WriteFile(path, file, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTags(path, testTags); err != nil {
+		t.Fatalf("WriteTags: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	atoms, err := parseAtoms(data)
+	if err != nil {
+		t.Fatalf("parseAtoms: %v", err)
+	}
+	if len(atoms) != 3 || atoms[1].typ != "moov" || atoms[2].typ != "mdat" {
+		t.Fatalf("top-level atoms = %v; want ftyp, moov, mdat", atoms)
+	}
+
+	find := func(data []byte, path ...string) atom {
+		t.Helper()
+		var found atom
+		for _, typ := range path {
+			children, err := parseAtoms(data)
+			if err != nil {
+				t.Fatalf("parseAtoms: %v", err)
+			}
+			ok := false
+			for _, c := range children {
+				if c.typ == typ {
+					found, ok = c, true
+					break
+				}
+			}
+			if !ok {
+				t.Fatalf("atom %q not found", typ)
+			}
+			data = found.body()
+			if typ == "meta" {
+				data = data[4:]
+			}
+		}
+		return found
+	}
+
+	offsets := find(atoms[1].data, "moov", "trak", "mdia", "minf", "stbl", "stco").body()
+	offset := binary.BigEndian.Uint32(offsets[8:])
+	if got := string(data[offset : offset+5]); got != "media" {
+		t.Errorf("chunk offset points at %q; want %q", got, "media")
+	}
+
+	find(atoms[1].data, "moov", "udta", "name")
+	ilst := find(atoms[1].data, "moov", "udta", "meta", "ilst").body()
+	for typ, want := range map[string]string{
+		"\xa9nam": "Song", "\xa9ART": "Artist", "\xa9alb": "Album", "aART": "Album Artist",
+		"\xa9day": "2020-05-01", "\xa9gen": "Rock", "\xa9wrt": "Composer",
+	} {
+		value := find(ilst, typ, "data").body()[8:]
+		if string(value) != want {
+			t.Errorf("%q = %q; want %q", typ, value, want)
+		}
+	}
+	if trkn := find(ilst, "trkn", "data").body()[8:]; binary.BigEndian.Uint16(trkn[2:]) != 3 || binary.BigEndian.Uint16(trkn[4:]) != 12 {
+		t.Errorf("trkn = %v; want track 3 of 12", trkn)
+	}
+	if covr := find(ilst, "covr", "data").body(); binary.BigEndian.Uint32(covr) != 13 || !bytes.Equal(covr[8:], testTags.Artwork) {
+		t.Errorf("covr = %q", covr)
+	}
+	if isrc := find(ilst, "----", "data").body()[8:]; string(isrc) != "USABC2000001" {
+		t.Errorf("ISRC = %q", isrc)
+	}
+}
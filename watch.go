@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var musicURLPattern = regexp.MustCompile(`^https?://(` +
+	`(geo\.)?music\.apple\.com|itunes\.apple\.com|` +
+	`open\.spotify\.com|spotify\.link|` +
+	`(www\.|m\.|music\.)?youtube\.com|youtu\.be|` +
+	`(listen\.|www\.)?tidal\.com|` +
+	`(www\.)?deezer\.com|deezer\.page\.link` +
+	`)/\S+$`)
+
+// isMusicURL reports whether text is a single URL from a supported music
+// service.
+func isMusicURL(text string) bool {
+	return musicURLPattern.MatchString(strings.TrimSpace(text))
+}
+
+// watchCacheSize is how many converted URLs the watcher remembers.
+const watchCacheSize = 100
+
+// ClipboardWatcher polls the clipboard and replaces music URLs with their
+// formatted share text.
+type ClipboardWatcher struct {
+	Interval time.Duration
+	Debounce time.Duration
+
+	read    func() (string, error)
+	write   func(string) error
+	resolve func(context.Context, string) (*LinksOutput, error)
+
+	cache       map[string]string
+	cacheOrder  []string // least recently used first
+	pending     string
+	changedAt   time.Time
+	handled     string
+	lastWritten string
+}
+
+func NewClipboardWatcher(interval, debounce time.Duration) *ClipboardWatcher {
+	return &ClipboardWatcher{
+		Interval: interval,
+		Debounce: debounce,
+		read:     ReadAll,
+		write:    WriteAll,
+		resolve:  ResolveLink,
+		cache:    make(map[string]string),
+	}
+}
+
+func (w *ClipboardWatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	// Whatever is on the clipboard when watching starts was not copied
+	// by the user while watching, so leave it alone.
+	if current, err := w.read(); err == nil {
+		w.pending = current
+		w.handled = current
+	} else {
+		return fmt.Errorf("error reading clipboard: %w", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			if err := w.poll(ctx, now); err != nil {
+				fmt.Fprintf(ui(), "❌ %v\n", err)
+			}
+		}
+	}
+}
+
+func (w *ClipboardWatcher) poll(ctx context.Context, now time.Time) error {
+	current, err := w.read()
+	if err != nil {
+		return fmt.Errorf("error reading clipboard: %w", err)
+	}
+
+	if current != w.pending {
+		w.pending = current
+		w.changedAt = now
+		return nil
+	}
+
+	if current == w.handled || now.Sub(w.changedAt) < w.Debounce {
+		return nil
+	}
+	w.handled = current
+
+	if current == w.lastWritten || !isMusicURL(current) {
+		return nil
+	}
+
+	searchURL := strings.TrimSpace(current)
+	output, ok := w.cached(searchURL)
+	if !ok {
+		result, err := w.resolve(ctx, searchURL)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error getting links for %s: %w", searchURL, err)
+		}
+		output = result.Output
+		w.remember(searchURL, output)
+	}
+
+	if err := w.write(output); err != nil {
+		return fmt.Errorf("error copying output string to clipboard: %w", err)
+	}
+	w.lastWritten = output
+	w.pending = output
+	w.handled = output
+
+	fmt.Fprintf(ui(), "✅ %s\n%s\n\n", searchURL, output)
+	return nil
+}
+
+// cached returns the conversion of url, marking it as recently used.
+func (w *ClipboardWatcher) cached(url string) (string, bool) {
+	output, ok := w.cache[url]
+	if ok {
+		w.touch(url)
+	}
+	return output, ok
+}
+
+// remember caches output for url, dropping the least recently used URL once
+// watchCacheSize are cached.
+func (w *ClipboardWatcher) remember(url, output string) {
+	if _, ok := w.cache[url]; !ok && len(w.cache) >= watchCacheSize {
+		delete(w.cache, w.cacheOrder[0])
+		w.cacheOrder = w.cacheOrder[1:]
+	}
+	w.cache[url] = output
+	w.touch(url)
+}
+
+func (w *ClipboardWatcher) touch(url string) {
+	for i, u := range w.cacheOrder {
+		if u == url {
+			w.cacheOrder = append(w.cacheOrder[:i], w.cacheOrder[i+1:]...)
+			break
+		}
+	}
+	w.cacheOrder = append(w.cacheOrder, url)
+}
+
+func executeWatch(args []string) error {
+	watchCmd := flag.NewFlagSet("watch", flag.ExitOnError)
+	intervalFlag := watchCmd.Duration("interval", 500*time.Millisecond, "How often to check the clipboard")
+	debounceFlag := watchCmd.Duration("debounce", time.Second, "How long a copied URL must stay on the clipboard before it is converted")
+	registerOutputFlags(watchCmd)
+	registerLookupFlags(watchCmd)
+	helpFlag := watchCmd.Bool("help", false, "Show help for watch command")
+	hFlag := watchCmd.Bool("h", false, "Show help for watch command")
+
+	if err := watchCmd.Parse(reorderArgs(args, map[string]bool{"interval": true, "debounce": true, "platform": true, "template": true, "country": true})); err != nil {
+		return err
+	}
+
+	if *helpFlag || *hFlag {
+		printWatchHelp()
+		os.Exit(0)
+	}
+
+	if *intervalFlag <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Fprintln(ui(), "Watching the clipboard for music links (Ctrl-C to stop)...")
+	return NewClipboardWatcher(*intervalFlag, *debounceFlag).Run(ctx)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestClipboardWatcherPoll(t *testing.T) {
+	clipboardText := "some text"
+	resolved := 0
+	ctx := context.Background()
+	w := NewClipboardWatcher(time.Millisecond, time.Second)
+	w.read = func() (string, error) { return clipboardText, nil }
+	w.write = func(text string) error {
+		clipboardText = text
+		return nil
+	}
+	w.resolve = func(ctx context.Context, searchURL string) (*LinksOutput, error) {
+		resolved++
+		return &LinksOutput{URL: searchURL, Output: "https://song.link/i/1"}, nil
+	}
+
+	start := time.Now()
+	appleURL := "https://music.apple.com/us/album/caravan/1572919347?i=1572919354"
+
+	clipboardText = appleURL
+	w.poll(ctx, start)
+	w.poll(ctx, start.Add(500*time.Millisecond))
+	if clipboardText != appleURL {
+		t.Fatalf("clipboard converted before the debounce elapsed")
+	}
+
+	w.poll(ctx, start.Add(1500*time.Millisecond))
+	if clipboardText != "https://song.link/i/1" {
+		t.Fatalf("clipboard = %q; want the converted link", clipboardText)
+	}
+
+	w.poll(ctx, start.Add(3*time.Second))
+	w.poll(ctx, start.Add(5*time.Second))
+	if resolved != 1 {
+		t.Errorf("resolved %d times after the watcher's own write; want 1", resolved)
+	}
+
+	clipboardText = appleURL
+	w.poll(ctx, start.Add(6*time.Second))
+	w.poll(ctx, start.Add(8*time.Second))
+	if clipboardText != "https://song.link/i/1" || resolved != 1 {
+		t.Errorf("repeated copy: clipboard = %q, resolved = %d; want cached conversion", clipboardText, resolved)
+	}
+}
+
+func TestIsMusicURL(t *testing.T) {
+	tests := map[string]bool{
+		"https://music.apple.com/us/album/caravan/1572919347?i=1572919354": true,
+		"https://open.spotify.com/track/2Xtsv7BUMrNodQWH2JPOc0":            true,
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ":                      true,
+		"https://listen.tidal.com/track/189838410":                         true,
+		"https://song.link/i/1572919354":                                   false,
+		"https://example.com/track/1":                                      false,
+		"check this out https://open.spotify.com/track/1":                  false,
+	}
+	for input, expected := range tests {
+		if got := isMusicURL(input); got != expected {
+			t.Errorf("isMusicURL(%q) = %v; want %v", input, got, expected)
+		}
+	}
+}
+
+func TestClipboardWatcherCacheLimit(t *testing.T) {
+	w := NewClipboardWatcher(time.Millisecond, time.Second)
+	for i := 0; i < watchCacheSize; i++ {
+		w.remember(fmt.Sprintf("https://open.spotify.com/track/%d", i), "out")
+	}
+	// Using the oldest entry keeps it; the next insert evicts track 1.
+	if _, ok := w.cached("https://open.spotify.com/track/0"); !ok {
+		t.Fatal("track 0 not cached")
+	}
+	w.remember("https://open.spotify.com/track/new", "out")
+
+	if len(w.cache) != watchCacheSize || len(w.cacheOrder) != watchCacheSize {
+		t.Errorf("cache holds %d entries (%d ordered); want %d", len(w.cache), len(w.cacheOrder), watchCacheSize)
+	}
+	if _, ok := w.cache["https://open.spotify.com/track/1"]; ok {
+		t.Error("least recently used track 1 still cached")
+	}
+	if _, ok := w.cache["https://open.spotify.com/track/0"]; !ok {
+		t.Error("recently used track 0 evicted")
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DownloadProgress is how far yt-dlp has got with a download. Fields yt-dlp
+// doesn't know yet are zero.
+type DownloadProgress struct {
+	Downloaded int64
+	Total      int64
+	Speed      float64 // bytes per second
+	ETA        time.Duration
+}
+
+// ProgressFunc receives download progress as yt-dlp reports it. It may be
+// nil.
+type ProgressFunc func(DownloadProgress)
+
+// Percent returns the share of Total downloaded, or 0 when Total is unknown.
+func (p DownloadProgress) Percent() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	return min(100, float64(p.Downloaded)/float64(p.Total)*100)
+}
+
+func (p DownloadProgress) String() string {
+	if p.Total <= 0 {
+		return formatBytes(float64(p.Downloaded))
+	}
+	s := fmt.Sprintf("%.1f%% of %s", p.Percent(), formatBytes(float64(p.Total)))
+	if p.Speed > 0 {
+		s += fmt.Sprintf(" at %s/s", formatBytes(p.Speed))
+	}
+	if p.ETA > 0 {
+		s += fmt.Sprintf(", ETA %s", p.ETA)
+	}
+	return s
+}
+
+func formatBytes(n float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB"}
+	i := 0
+	for n >= 1024 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", n, units[i])
+	}
+	return fmt.Sprintf("%.1f %s", n, units[i])
+}
+
+const ytdlpProgressPrefix = "[songlink-progress]"
+
+// ytdlpProgressArgs make yt-dlp print one machine-readable line per progress
+// update. Unknown values come out as "NA".
+var ytdlpProgressArgs = []string{
+	"--newline",
+	"--progress-template",
+	"download:" + ytdlpProgressPrefix + " %(progress.downloaded_bytes)s %(progress.total_bytes)s %(progress.total_bytes_estimate)s %(progress.speed)s %(progress.eta)s",
+}
+
+// parseProgressLine parses a line printed with ytdlpProgressArgs.
+func parseProgressLine(line string) (DownloadProgress, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line), ytdlpProgressPrefix)
+	if !ok {
+		return DownloadProgress{}, false
+	}
+	fields := strings.Fields(rest)
+	if len(fields) != 5 {
+		return DownloadProgress{}, false
+	}
+
+	number := func(s string) float64 {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0
+		}
+		return v
+	}
+	p := DownloadProgress{
+		Downloaded: int64(number(fields[0])),
+		Total:      int64(number(fields[1])),
+		Speed:      number(fields[3]),
+		ETA:        time.Duration(number(fields[4])) * time.Second,
+	}
+	if p.Total == 0 {
+		p.Total = int64(number(fields[2]))
+	}
+	return p, true
+}
+
+// progressWriter is used as yt-dlp's stdout. Progress lines go to onProgress;
+// everything else, and progress lines too when debugging, goes to out.
+type progressWriter struct {
+	out        io.Writer
+	onProgress ProgressFunc
+	debug      bool
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func newProgressWriter(out io.Writer, onProgress ProgressFunc, debug bool) *progressWriter {
+	return &progressWriter{out: out, onProgress: onProgress, debug: debug}
+}
+
+func (w *progressWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, data...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(w.buf[:i+1])
+		w.buf = w.buf[i+1:]
+
+		if p, ok := parseProgressLine(line); ok {
+			if w.onProgress != nil {
+				w.onProgress(p)
+			}
+			if !w.debug {
+				continue
+			}
+		}
+		io.WriteString(w.out, line)
+	}
+	return len(data), nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseProgressLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want DownloadProgress
+		ok   bool
+	}{
+		{
+			"[songlink-progress] 1048576 4194304 NA 524288.5 6\n",
+			DownloadProgress{Downloaded: 1048576, Total: 4194304, Speed: 524288.5, ETA: 6 * time.Second},
+			true,
+		},
+		{
+			"[songlink-progress] 2048 NA 8192.0 NA NA",
+			DownloadProgress{Downloaded: 2048, Total: 8192},
+			true,
+		},
+		{"[download] Destination: song.webm", DownloadProgress{}, false},
+		{"[songlink-progress] 1 2", DownloadProgress{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseProgressLine(tt.line)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseProgressLine(%q) = %+v, %t; want %+v, %t", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	if p := (DownloadProgress{Downloaded: 1048576, Total: 4194304, Speed: 524288, ETA: 6 * time.Second}); p.String() != "25.0% of 4.0 MiB at 512.0 KiB/s, ETA 6s" {
+		t.Errorf("String() = %q", p.String())
+	}
+}
+
+func TestProgressWriter(t *testing.T) {
+	var out strings.Builder
+	var updates []DownloadProgress
+	w := newProgressWriter(&out, func(p DownloadProgress) { updates = append(updates, p) }, false)
+
+	// Lines may be split across writes.
+	w.Write([]byte("[youtube] abc: Downloading webpage\n[songlink-progress] 10 100 NA NA NA\n[songlink-pro"))
+	w.Write([]byte("gress] 100 100 NA NA NA\n"))
+
+	if len(updates) != 2 || updates[1].Percent() != 100 {
+		t.Errorf("updates = %+v; want two, ending at 100%%", updates)
+	}
+	if got := out.String(); got != "[youtube] abc: Downloading webpage\n" {
+		t.Errorf("forwarded output = %q; want only the non-progress line", got)
+	}
+}